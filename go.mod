@@ -4,6 +4,7 @@ go 1.16
 
 require (
 	github.com/davecgh/go-spew v1.1.1
+	github.com/fsnotify/fsnotify v1.4.9
 	github.com/gonvenience/bunt v1.3.2
 	github.com/gonvenience/neat v1.3.6
 	github.com/gonvenience/term v1.0.1
@@ -11,11 +12,11 @@ require (
 	github.com/gonvenience/wrap v1.1.0
 	github.com/gonvenience/ytbx v1.4.2
 	github.com/lucasb-eyer/go-colorful v1.2.0
-	github.com/mitchellh/hashstructure v1.1.0
 	github.com/onsi/ginkgo v1.16.4
 	github.com/onsi/gomega v1.13.0
 	github.com/sergi/go-diff v1.2.0
 	github.com/spf13/cobra v1.2.1
+	github.com/spf13/pflag v1.0.5
 	github.com/texttheater/golang-levenshtein v1.0.1
 	gopkg.in/yaml.v3 v3.0.0-20210107192922-496545a6307b
 )
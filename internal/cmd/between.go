@@ -21,6 +21,9 @@
 package cmd
 
 import (
+	"fmt"
+	"os"
+
 	"github.com/gonvenience/wrap"
 	"github.com/gonvenience/ytbx"
 	"github.com/spf13/cobra"
@@ -34,6 +37,8 @@ type betweenCmdOptions struct {
 	chroot                   string
 	chrootFrom               string
 	chrootTo                 string
+	kubectlDiff              bool
+	watch                    bool
 }
 
 var betweenCmdSettings betweenCmdOptions
@@ -45,6 +50,10 @@ var betweenCmd = &cobra.Command{
 	Long: `
 Compares differences between files and displays the delta. Supported input file
 types are: YAML (http://yaml.org/) and JSON (http://json.org/).
+
+In case both from and to are directories, the files in both directory trees
+are paired by their relative path and compared with each other. Files that
+only exist in one of the directories are reported as added or removed.
 `,
 	Args:    cobra.ExactArgs(2),
 	Aliases: []string{"bw"},
@@ -58,55 +67,120 @@ types are: YAML (http://yaml.org/) and JSON (http://json.org/).
 			toLocation = args[1]
 		}
 
-		from, to, err := ytbx.LoadFiles(fromLocation, toLocation)
-		if err != nil {
-			return wrap.Errorf(err, "failed to load input files")
+		if betweenCmdSettings.watch {
+			return watchBetween(cmd, fromLocation, toLocation)
 		}
 
-		// If the main change root flag is set, this (re-)sets the individual change roots of the two input files
-		if betweenCmdSettings.chroot != "" {
-			betweenCmdSettings.chrootFrom = betweenCmdSettings.chroot
-			betweenCmdSettings.chrootTo = betweenCmdSettings.chroot
-		}
-
-		// Change root of 'from' input file if change root flag for 'from' is set
-		if betweenCmdSettings.chrootFrom != "" {
-			if err = dyff.ChangeRoot(&from, betweenCmdSettings.chrootFrom, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
-				return wrap.Errorf(err, "failed to change root of %s to path %s", from.Location, betweenCmdSettings.chrootFrom)
+		if isDirectory(fromLocation) && isDirectory(toLocation) {
+			if betweenCmdSettings.kubectlDiff {
+				return compareKubectlDiffDirectories(cmd, fromLocation, toLocation)
 			}
-		}
 
-		// Change root of 'to' input file if change root flag for 'to' is set
-		if betweenCmdSettings.chrootTo != "" {
-			if err = dyff.ChangeRoot(&to, betweenCmdSettings.chrootTo, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
-				return wrap.Errorf(err, "failed to change root of %s to path %s", to.Location, betweenCmdSettings.chrootTo)
+			report, err := compareDirectories(fromLocation, toLocation)
+			if err != nil {
+				return err
 			}
+
+			return writeDirectoryReport(cmd, report)
 		}
 
-		report, err := dyff.CompareInputFiles(from, to,
-			dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges),
-			dyff.KubernetesEntityDetection(reportOptions.kubernetesEntityDetection),
-		)
+		report, err := compareFiles(fromLocation, toLocation)
 		if err != nil {
-			return wrap.Errorf(err, "failed to compare input files")
+			return err
 		}
 
-		if reportOptions.filters != nil {
-			var filterPaths []ytbx.Path
-			for _, pathString := range reportOptions.filters {
-				path, err := ytbx.ParsePathStringUnsafe(pathString)
-				if err != nil {
-					return wrap.Errorf(err, "failed to set path filter, because path %s cannot be parsed", pathString)
-				}
+		return writeReport(cmd, report)
+	},
+}
 
-				filterPaths = append(filterPaths, path)
-			}
+func isDirectory(location string) bool {
+	info, err := os.Stat(location)
+	return err == nil && info.IsDir()
+}
 
-			report = report.Filter(filterPaths...)
+// compareFiles loads and compares the two input files with the configured
+// change root, compare options, and filters applied
+func compareFiles(fromLocation string, toLocation string) (dyff.Report, error) {
+	from, to, err := ytbx.LoadFiles(fromLocation, toLocation)
+	if err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to load input files")
+	}
+
+	// If the main change root flag is set, this (re-)sets the individual change roots of the two input files
+	if betweenCmdSettings.chroot != "" {
+		betweenCmdSettings.chrootFrom = betweenCmdSettings.chroot
+		betweenCmdSettings.chrootTo = betweenCmdSettings.chroot
+	}
+
+	// Change root of 'from' input file if change root flag for 'from' is set
+	if betweenCmdSettings.chrootFrom != "" {
+		if err = dyff.ChangeRoot(&from, betweenCmdSettings.chrootFrom, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
+			return dyff.Report{}, wrap.Errorf(err, "failed to change root of %s to path %s", from.Location, betweenCmdSettings.chrootFrom)
 		}
+	}
 
-		return writeReport(cmd, report)
-	},
+	// Change root of 'to' input file if change root flag for 'to' is set
+	if betweenCmdSettings.chrootTo != "" {
+		if err = dyff.ChangeRoot(&to, betweenCmdSettings.chrootTo, reportOptions.useGoPatchPaths, betweenCmdSettings.translateListToDocuments); err != nil {
+			return dyff.Report{}, wrap.Errorf(err, "failed to change root of %s to path %s", to.Location, betweenCmdSettings.chrootTo)
+		}
+	}
+
+	options, err := compareOptions()
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	report, err := dyff.CompareInputFiles(from, to, options...)
+	if err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to compare input files")
+	}
+
+	return applyFilters(report)
+}
+
+func compareDirectories(from string, to string) (dyff.DirectoryReport, error) {
+	if betweenCmdSettings.chroot != "" || betweenCmdSettings.chrootFrom != "" || betweenCmdSettings.chrootTo != "" {
+		return dyff.DirectoryReport{}, fmt.Errorf("changing the root level is not supported when comparing directories")
+	}
+
+	filter, err := directoryFilter()
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	options, err := compareOptions()
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	report, err := dyff.CompareDirectories(from, to, filter, options...)
+	if err != nil {
+		return dyff.DirectoryReport{}, wrap.Errorf(err, "failed to compare directories")
+	}
+
+	return report, nil
+}
+
+// compareKubectlDiffDirectories compares the directories created by `kubectl
+// diff` as one combined report, which matches the files by their name
+func compareKubectlDiffDirectories(cmd *cobra.Command, from string, to string) error {
+	options, err := compareOptions()
+	if err != nil {
+		return err
+	}
+
+	report, err := dyff.CompareKubectlDiffDirectories(from, to, options...)
+	if err != nil {
+		return wrap.Errorf(err, "failed to compare kubectl diff directories")
+	}
+
+	report, err = applyFilters(report)
+	if err != nil {
+		return err
+	}
+
+	return writeReport(cmd, report)
 }
 
 func init() {
@@ -122,5 +196,6 @@ func init() {
 	betweenCmd.PersistentFlags().StringVar(&betweenCmdSettings.chroot, "chroot", "", "change the root level of the input file to another point in the document")
 	betweenCmd.PersistentFlags().StringVar(&betweenCmdSettings.chrootFrom, "chroot-of-from", "", "only change the root level of the from input file")
 	betweenCmd.PersistentFlags().StringVar(&betweenCmdSettings.chrootTo, "chroot-of-to", "", "only change the root level of the to input file")
+	betweenCmd.PersistentFlags().BoolVar(&betweenCmdSettings.watch, "watch", false, "watch the input files or directories and show the report again whenever they change")
 	betweenCmd.PersistentFlags().BoolVar(&betweenCmdSettings.translateListToDocuments, "chroot-list-to-documents", false, "in case the change root points to a list, treat this list as a set of documents and not as the list itself")
 }
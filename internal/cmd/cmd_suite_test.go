@@ -82,6 +82,34 @@ func assets(pathElement ...string) string {
 	return abs
 }
 
+// setenv sets the environment variable and returns a function to restore the
+// previous state, which unsets the variable if it was not set before
+func setenv(name string, value string) func() {
+	tmp, ok := os.LookupEnv(name)
+	Expect(os.Setenv(name, value)).To(Succeed())
+
+	return func() {
+		if ok {
+			Expect(os.Setenv(name, tmp)).To(Succeed())
+		} else {
+			Expect(os.Unsetenv(name)).To(Succeed())
+		}
+	}
+}
+
+// unsetenv unsets the environment variable and returns a function to restore
+// the previous state
+func unsetenv(name string) func() {
+	tmp, ok := os.LookupEnv(name)
+	Expect(os.Unsetenv(name)).To(Succeed())
+
+	return func() {
+		if ok {
+			Expect(os.Setenv(name, tmp)).To(Succeed())
+		}
+	}
+}
+
 func captureStdout(f func() error) (string, error) {
 	r, w, err := os.Pipe()
 	Expect(err).ToNot(HaveOccurred())
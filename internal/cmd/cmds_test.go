@@ -21,9 +21,14 @@
 package cmd_test
 
 import (
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -52,6 +57,26 @@ var _ = Describe("command line tool flags", func() {
 		})
 	})
 
+	Context("theme flag", func() {
+		It("should fail when an unknown theme is specified", func() {
+			_, err := dyff("yaml", "--theme", "does-not-exist", assets("examples", "from.yml"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("failed to set theme"))
+		})
+
+		It("should use the theme from the environment variable if the flag is not set", func() {
+			var tmp = os.Getenv("DYFF_THEME")
+			os.Setenv("DYFF_THEME", "does-not-exist")
+			defer os.Setenv("DYFF_THEME", tmp)
+
+			_, err := dyff("yaml", assets("examples", "from.yml"))
+			Expect(err).To(HaveOccurred())
+
+			_, err = dyff("yaml", "--theme", "light", assets("examples", "from.yml"))
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
 	Context("yaml command", func() {
 		Context("creating yaml output", func() {
 			It("should not create YAML output that is not valid", func() {
@@ -277,6 +302,30 @@ list
 			Expect(out).To(BeEquivalentTo(fmt.Sprintf("one change detected between %s and %s\n\n", from, to)))
 		})
 
+		It("should create the statistics report", func() {
+			from := createTestFile(`{"list":[{"aaa":"bbb","name":"one"}],"foo":"bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"list":[{"aaa":"bbb","name":"two"}],"foo":"BAR"}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--output=stats", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+statistics of two differences
+
+  change kind      count
+  + addition           1
+  - removal            1
+  ± modification       1
+
+  top-level key   count
+  foo                 1
+  list                1
+
+`))
+		})
+
 		It("should create a report using a custom root in the files", func() {
 			from, to := assets("examples", "from.yml"), assets("examples", "to.yml")
 			expected := fmt.Sprintf(`     _        __  __
@@ -392,6 +441,120 @@ list
 			Expect(out).To(BeEquivalentTo("\n"))
 		})
 
+		It("should use ASCII change indicators if respective flag is set", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--ascii", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+foo
+  ~ value change
+    - bar
+    + BAR
+
+`))
+		})
+
+		It("should write the report directly if the output is not a terminal", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "BAR"}`)
+			defer os.Remove(to)
+
+			defer setenv("DYFF_PAGER", "false")()
+
+			for _, args := range [][]string{{"between", "--omit-header", from, to}, {"between", "--omit-header", "--no-pager", from, to}} {
+				out, err := dyff(args...)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(BeEquivalentTo(`
+foo
+  ± value change
+    - bar
+    + BAR
+
+`))
+			}
+		})
+
+		Context("using a pager", func() {
+			var from, to string
+			var restore []func()
+
+			BeforeEach(func() {
+				from = createTestFile(`{"foo": "bar"}`)
+				to = createTestFile(`{"foo": "BAR"}`)
+
+				tmp := term.FixedTerminalHeight
+				term.FixedTerminalHeight = 2
+
+				restore = []func(){
+					func() { term.FixedTerminalHeight = tmp },
+					SetIsTerminal(func() bool { return true }),
+					unsetenv("DYFF_PAGER"),
+					unsetenv("PAGER"),
+					unsetenv("LESS"),
+				}
+			})
+
+			AfterEach(func() {
+				os.Remove(from)
+				os.Remove(to)
+
+				for _, f := range restore {
+					f()
+				}
+			})
+
+			It("should pipe the report through the pager configured in DYFF_PAGER", func() {
+				defer setenv("PAGER", "false")()
+				defer setenv("DYFF_PAGER", "tr a-z A-Z")()
+
+				out, err := dyff("between", "--omit-header", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(BeEquivalentTo(`
+FOO
+  ± VALUE CHANGE
+    - BAR
+    + BAR
+
+`))
+			})
+
+			It("should fall back to the pager configured in PAGER with default less settings", func() {
+				defer setenv("PAGER", `cat >/dev/null; echo "LESS=$LESS"`)()
+
+				out, err := dyff("between", "--omit-header", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(BeEquivalentTo("LESS=FRX\n"))
+			})
+
+			It("should not use a pager if the report fits on the screen or paging is disabled", func() {
+				defer setenv("DYFF_PAGER", "tr a-z A-Z")()
+
+				term.FixedTerminalHeight = 100
+				out, err := dyff("between", "--omit-header", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(ContainSubstring("value change"))
+
+				term.FixedTerminalHeight = 2
+				out, err = dyff("between", "--omit-header", "--no-pager", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(out).To(ContainSubstring("value change"))
+			})
+
+			It("should fail if the pager fails", func() {
+				defer setenv("DYFF_PAGER", "false")()
+
+				_, err := dyff("between", "--omit-header", from, to)
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
 		It("should not panic when timestamps need to reported", func() {
 			out, err := dyff("between", "--omit-header", "../../assets/issues/issue-111/from.yml", "../../assets/issues/issue-111/to.yml")
 			Expect(err).ToNot(HaveOccurred())
@@ -443,7 +606,376 @@ example_two
 			defer os.Setenv("KUBECTL_EXTERNAL_DIFF", tmp)
 
 			_, err = dyff(from, to, "between", "--omit-header")
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+		})
+
+		It("should compare the directories of kubectl diff by file name without server managed fields", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for name, content := range map[string]string{
+				filepath.Join(from, "apps.v1.Deployment.default.app"): `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  uid: 1d2b1c4e-0e4f-4a4e-9d2a-7c0d5e0c9a11
+  resourceVersion: "4711"
+  generation: 3
+  creationTimestamp: "2021-01-01T00:00:00Z"
+  managedFields:
+  - manager: kubectl
+    operation: Update
+spec:
+  replicas: 1
+status:
+  replicas: 1
+`,
+				filepath.Join(to, "apps.v1.Deployment.default.app"): `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  uid: 1d2b1c4e-0e4f-4a4e-9d2a-7c0d5e0c9a11
+  resourceVersion: "4712"
+  generation: 4
+  creationTimestamp: "2021-01-01T00:00:00Z"
+spec:
+  replicas: 2
+`,
+				filepath.Join(from, "v1.ConfigMap.default.same"): "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: same\n  uid: abc\n",
+				filepath.Join(to, "v1.ConfigMap.default.same"):   "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: same\n  uid: xyz\n",
+			} {
+				Expect(ioutil.WriteFile(name, []byte(content), os.FileMode(0644))).To(Succeed())
+			}
+
+			defer setenv("KUBECTL_EXTERNAL_DIFF", "cmd.test between --omit-header")()
+
+			out, err := dyff("between", "--omit-header", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(BeEquivalentTo(`
+spec.replicas  (apps.v1.Deployment.default.app)
+  ± value change
+    - 1
+    + 2
+
+`))
+
+			// kubectl treats exit codes other than 0 and 1 as failures
+			policy := createTestFile(`{"rules": [{"path": "/spec/replicas", "severity": "error"}]}`)
+			defer os.Remove(policy)
+
+			for _, args := range [][]string{
+				{"--policy", policy},
+				{"--exit-code-mode", "kinds"},
+			} {
+				_, err = dyff(append([]string{"between", "--omit-header"}, append(args, from, to)...)...)
+				Expect(err).To(HaveOccurred())
+				Expect(err.(ExitCode).Value).To(Equal(1))
+			}
+
+			Expect(os.Remove(filepath.Join(to, "apps.v1.Deployment.default.app"))).To(Succeed())
+			Expect(os.Remove(filepath.Join(from, "apps.v1.Deployment.default.app"))).To(Succeed())
+
+			_, err = dyff("between", "--omit-header", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+		})
+
+		It("should compare two directories file by file", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for dir, files := range map[string]map[string]string{
+				from: {"same.yml": "foo: bar", "changed.yml": "foo: bar", "removed.yml": "foo: bar", "sub/ignored.txt": "text", "NOTES.txt": "Thank you:\n  {{ .Release.Name }}: - installed"},
+				to:   {"same.yml": "foo: bar", "changed.yml": "foo: BAR", "added.yml": "foo: bar", "sub/ignored.txt": "other", "NOTES.txt": "Thank you:\n  {{ .Release.Name }}: - upgraded"},
+			} {
+				for name, content := range files {
+					Expect(os.MkdirAll(filepath.Dir(filepath.Join(dir, name)), os.FileMode(0755))).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), os.FileMode(0644))).To(Succeed())
+				}
+			}
+
+			out, err := dyff("between", "--exclude", "*.txt", "--set-exit-code", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(BeEquivalentTo(fmt.Sprintf(`one file changed, one file added, and one file removed between %s and %s
+
++ file added: added.yml
+
+± file changed: changed.yml
+
+foo
+  ± value change
+    - bar
+    + BAR
+
+
+- file removed: removed.yml
+`, from, to)))
+
+			out, err = dyff("between", "--exclude", "*.txt", "--ascii", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("\n~ file changed: changed.yml\n"))
+			Expect(out).ToNot(ContainSubstring("±"))
+
+			out, err = dyff("between", "--include", "same.yml", "--set-exit-code", "--omit-header", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(BeEmpty())
+
+			out, err = dyff("between", "--set-exit-code", "--omit-header", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(ContainSubstring("file changed: changed.yml"))
+			Expect(out).ToNot(ContainSubstring("NOTES.txt"))
+
+			out, err = dyff("between", "--set-exit-code", "--filter", "/nope", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(BeEquivalentTo(fmt.Sprintf("no files changed, no files added, and no files removed between %s and %s\n", from, to)))
+
+			out, err = dyff("between", "--set-exit-code", "--fail-on", "addition", "--filter", "/foo", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(ContainSubstring("file changed: changed.yml"))
+			Expect(out).ToNot(ContainSubstring("added.yml"))
+
+			out, err = dyff("between", "--output", "json", from, to)
+			Expect(err).ToNot(HaveOccurred())
+
+			var result struct {
+				From     string `json:"from"`
+				Sections []struct {
+					Name   string `json:"name"`
+					Change string `json:"change"`
+					Report *struct {
+						Diffs []struct {
+							Path string `json:"path"`
+						} `json:"diffs"`
+					} `json:"report"`
+				} `json:"sections"`
+			}
+
+			Expect(json.Unmarshal([]byte(out), &result)).To(Succeed())
+			Expect(result.From).To(Equal(from))
+			Expect(result.Sections).To(HaveLen(3))
+			Expect(result.Sections[0].Name).To(Equal("added.yml"))
+			Expect(result.Sections[0].Change).To(Equal("added"))
+			Expect(result.Sections[0].Report).To(BeNil())
+			Expect(result.Sections[1].Name).To(Equal("changed.yml"))
+			Expect(result.Sections[1].Change).To(Equal("changed"))
+			Expect(result.Sections[1].Report.Diffs).To(HaveLen(1))
+			Expect(result.Sections[1].Report.Diffs[0].Path).To(Equal("/foo"))
+			Expect(result.Sections[2].Change).To(Equal("removed"))
+		})
+
+		It("should report files that cannot be compared and keep going", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for dir, files := range map[string]map[string]string{
+				from: {"changed.yml": "foo: bar", "multi.yml": "---\nfoo: bar\n"},
+				to:   {"changed.yml": "foo: BAR", "multi.yml": "---\nfoo: bar\n---\nfoo: baz\n"},
+			} {
+				for name, content := range files {
+					Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), os.FileMode(0644))).To(Succeed())
+				}
+			}
+
+			out, err := dyff("between", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(255))
+			Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("failed to compare one file"))
+			Expect(out).To(ContainSubstring("one file changed, no files added, and no files removed"))
+			Expect(out).To(ContainSubstring("one file could not be compared"))
+			Expect(out).To(ContainSubstring("file changed: changed.yml"))
+			Expect(out).To(ContainSubstring("! file failed: multi.yml"))
+			Expect(out).To(ContainSubstring("different number of documents"))
+
+			out, _ = dyff("between", "--output", "json", from, to)
+
+			var result struct {
+				Sections []struct {
+					Name   string `json:"name"`
+					Change string `json:"change"`
+					Error  string `json:"error"`
+				} `json:"sections"`
+			}
+
+			Expect(json.Unmarshal([]byte(out), &result)).To(Succeed())
+			Expect(result.Sections).To(HaveLen(2))
+			Expect(result.Sections[1].Name).To(Equal("multi.yml"))
+			Expect(result.Sections[1].Change).To(Equal("failed"))
+			Expect(result.Sections[1].Error).To(ContainSubstring("different number of documents"))
+		})
+
+		It("should fail for malformed include or exclude patterns", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for _, flag := range []string{"--include", "--exclude"} {
+				_, err := dyff("between", flag, "[abc", from, to)
+				Expect(err).To(HaveOccurred())
+				Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("invalid file pattern [abc"))
+			}
+
+			_, err := dyff("resources", "--exclude", "[abc", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("invalid file pattern [abc"))
+		})
+
+		It("should compare Kubernetes resources by identity across files", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for name, content := range map[string]string{
+				filepath.Join(from, "all.yml"): `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 1
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cfg
+data:
+  foo: bar
+`,
+				filepath.Join(from, "kustomization.yml"): "apiVersion: kustomize.config.k8s.io/v1beta1\nkind: Kustomization\n",
+				filepath.Join(to, "apps", "deployment.yml"): `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+spec:
+  replicas: 1
+`,
+				filepath.Join(to, "configmap.yml"): `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: cfg
+data:
+  foo: BAR
+`,
+				filepath.Join(to, "service.json"): `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "app", "namespace": "default"}}`,
+			} {
+				Expect(os.MkdirAll(filepath.Dir(name), os.FileMode(0755))).To(Succeed())
+				Expect(ioutil.WriteFile(name, []byte(content), os.FileMode(0644))).To(Succeed())
+			}
+
+			out, err := dyff("resources", "--set-exit-code", from, "--", filepath.Join(to, "apps"), filepath.Join(to, "configmap.yml"), filepath.Join(to, "service.json"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(BeEquivalentTo(fmt.Sprintf(`one resource changed, one resource added, and no resources removed between %s and %s, %s, %s
+
+± resource changed: ConfigMap/cfg (v1)
+  from %s (document #2)
+  to %s
+
+data.foo
+  ± value change
+    - bar
+    + BAR
+
+
++ resource added: Service/default/app (v1)
+  in %s
+`,
+				from,
+				filepath.Join(to, "apps"),
+				filepath.Join(to, "configmap.yml"),
+				filepath.Join(to, "service.json"),
+				filepath.Join(from, "all.yml"),
+				filepath.Join(to, "configmap.yml"),
+				filepath.Join(to, "service.json"),
+			)))
+
+			toArgs := []string{filepath.Join(to, "apps"), filepath.Join(to, "configmap.yml"), filepath.Join(to, "service.json")}
+
+			out, err = dyff(append([]string{"resources", "--set-exit-code", "--filter", "/nope", from, "--"}, toArgs...)...)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(HavePrefix("no resources changed, no resources added, and no resources removed"))
+
+			out, err = dyff(append([]string{"resources", "--set-exit-code", "--omit-header", "--fail-on", "addition", "--filter", "/data/foo", from, "--"}, toArgs...)...)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(ContainSubstring("resource changed: ConfigMap/cfg (v1)"))
+			Expect(out).ToNot(ContainSubstring("Service"))
+
+			out, err = dyff(append([]string{"resources", "--output", "json", from, "--"}, toArgs...)...)
+			Expect(err).ToNot(HaveOccurred())
+
+			var result struct {
+				Sections []struct {
+					Name   string          `json:"name"`
+					Change string          `json:"change"`
+					From   string          `json:"from"`
+					To     string          `json:"to"`
+					Report json.RawMessage `json:"report"`
+				} `json:"sections"`
+			}
+
+			Expect(json.Unmarshal([]byte(out), &result)).To(Succeed())
+			Expect(result.Sections).To(HaveLen(2))
+			Expect(result.Sections[0].Name).To(Equal("ConfigMap/cfg (v1)"))
+			Expect(result.Sections[0].From).To(Equal(filepath.Join(from, "all.yml") + " (document #2)"))
+			Expect(result.Sections[0].To).To(Equal(filepath.Join(to, "configmap.yml")))
+			Expect(result.Sections[0].Report).ToNot(BeEmpty())
+			Expect(result.Sections[1].Change).To(Equal("added"))
+
+			_, err = dyff("resources", from, "--")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should ignore Kubernetes default values when normalization is enabled", func() {
+			from := createTestFile(`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "app"}, "spec": {"ports": [{"port": 80}]}}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "app"}, "spec": {"type": "ClusterIP", "sessionAffinity": "None", "ipFamilyPolicy": "SingleStack", "ports": [{"port": 80, "protocol": "TCP"}]}}`)
+			defer os.Remove(to)
+
+			out, err := dyff("between", "--omit-header", "--normalize-defaults", from, to)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+spec
+  + one map entry added:
+    ipFamilyPolicy: SingleStack
+
+`))
+
+			rules := createTestFile(`{"defaults": [{"apiVersion": "v1", "kind": "Service", "path": "/spec/ipFamilyPolicy", "value": "SingleStack"}]}`)
+			defer os.Remove(rules)
+
+			out, err = dyff("between", "--omit-header", "--defaults-file", rules, from, to)
 			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo("\n"))
 		})
 
 		It("should create exit code zero if there are no changes", func() {
@@ -550,6 +1082,132 @@ foo: bar
 			Expect(err).To(HaveOccurred())
 		})
 
+		It("should reconstruct the applied configuration from the managed fields of a server-side apply", func() {
+			kubeYAML := createTestFile(`---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  namespace: default
+  labels:
+    app: app
+  managedFields:
+  - manager: kubectl
+    operation: Apply
+    apiVersion: apps/v1
+    fieldsType: FieldsV1
+    fieldsV1:
+      f:metadata:
+        f:labels:
+          f:app: {}
+      f:spec:
+        f:replicas: {}
+        f:template:
+          f:spec:
+            f:containers:
+              k:{"name":"app"}:
+                .: {}
+                f:image: {}
+                f:name: {}
+  - manager: kube-controller-manager
+    operation: Update
+    apiVersion: apps/v1
+    fieldsType: FieldsV1
+    fieldsV1:
+      f:status:
+        f:replicas: {}
+spec:
+  replicas: 3
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        imagePullPolicy: IfNotPresent
+      - name: sidecar
+        image: sidecar:1.0
+status:
+  replicas: 3
+`)
+			defer os.Remove(kubeYAML)
+
+			out, err := dyff("last-applied", "--omit-header", kubeYAML)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+spec.template.spec.containers
+  + one list entry added:
+    - name: sidecar
+      image: "sidecar:1.0"
+
+spec.template.spec.containers.app
+  + one map entry added:
+    imagePullPolicy: IfNotPresent
+
+`))
+
+			_, err = dyff("last-applied", "--field-manager", "kubectl", "--omit-header", kubeYAML)
+			Expect(err).ToNot(HaveOccurred())
+
+			_, err = dyff("last-applied", "--field-manager", "unknown", kubeYAML)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("available field managers are: kube-controller-manager, kubectl"))
+		})
+
+		It("should compare each resource of a Kubernetes List against its own last applied configuration", func() {
+			kubeYAML := createTestFile(`---
+apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: one
+    namespace: default
+    annotations:
+      kubectl.kubernetes.io/last-applied-configuration: |
+        {"apiVersion":"v1","kind":"ConfigMap","metadata":{"annotations":{},"name":"one","namespace":"default"},"data":{"foo":"bar"}}
+  data:
+    foo: BAR
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: two
+    namespace: default
+  data:
+    foo: bar
+- apiVersion: v1
+  kind: ConfigMap
+  metadata:
+    name: three
+    namespace: default
+    annotations:
+      kubectl.kubernetes.io/last-applied-configuration: |
+        {"apiVersion":"v1","kind":"ConfigMap","metadata":{"annotations":{},"name":"three","namespace":"default"},"data":{"foo":"bar"}}
+  data:
+    foo: bar
+    extra: value
+`)
+			defer os.Remove(kubeYAML)
+
+			out, err := dyff("last-applied", "--omit-header", kubeYAML)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+data.foo  (ConfigMap/default/one (v1))
+  ± value change
+    - bar
+    + BAR
+
+data  (ConfigMap/default/three (v1))
+  + one map entry added:
+    extra: value
+
+`))
+
+			out, err = dyff("last-applied", kubeYAML)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("skipped one resource without last applied configuration: ConfigMap/default/two (v1)"))
+		})
+
 		It("should fail on an input file when the last applied configuration is not set", func() {
 			kubeYAML := createTestFile(`foo: bar`)
 			defer os.Remove(kubeYAML)
@@ -558,4 +1216,475 @@ foo: bar
 			Expect(err).To(HaveOccurred())
 		})
 	})
+
+	Context("git command", func() {
+		It("should compare files between a revision and the working tree", func() {
+			repo := createTestDirectory()
+			defer os.RemoveAll(repo)
+
+			git := func(args ...string) {
+				cmd := exec.Command("git", append([]string{"-C", repo, "-c", "user.name=dyff", "-c", "user.email=dyff@example.org"}, args...)...)
+				out, err := cmd.CombinedOutput()
+				Expect(err).ToNot(HaveOccurred(), string(out))
+			}
+
+			git("init", "--quiet")
+			Expect(ioutil.WriteFile(filepath.Join(repo, "config.yml"), []byte("foo: bar\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "removed.yml"), []byte("foo: bar\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "other.yml"), []byte("foo: bar\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "main.go"), []byte("package main\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "logo.png"), []byte{0x89, 'P', 'N', 'G', 0x00, 0x01}, os.FileMode(0644))).To(Succeed())
+			git("add", ".")
+			git("commit", "--quiet", "--message", "initial")
+
+			Expect(ioutil.WriteFile(filepath.Join(repo, "config.yml"), []byte("foo: BAR\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "other.yml"), []byte("foo: BAR\n"), os.FileMode(0644))).To(Succeed())
+			Expect(ioutil.WriteFile(filepath.Join(repo, "main.go"), []byte("package other\n"), os.FileMode(0644))).To(Succeed())
+			Expect(os.Remove(filepath.Join(repo, "removed.yml"))).To(Succeed())
+
+			cwd, err := os.Getwd()
+			Expect(err).ToNot(HaveOccurred())
+			Expect(os.Chdir(repo)).To(Succeed())
+			defer os.Chdir(cwd)
+
+			out, err := dyff("git", "--exclude", "other.yml", "HEAD", "--", ".")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`one file changed, no files added, and one file removed between HEAD and working tree
+
+± file changed: config.yml
+
+foo
+  ± value change
+    - bar
+    + BAR
+
+
+- file removed: removed.yml
+`))
+
+			out, err = dyff("git", "--include", "other.yml", "--omit-header", "HEAD", "--", ".")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("file changed: other.yml"))
+			Expect(out).ToNot(ContainSubstring("config.yml"))
+
+			_, err = dyff("git", "HEAD", "--", "main.go")
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("no YAML or JSON files found in main.go"))
+
+			_, err = dyff("git", "HEAD", "config.yml")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should support the calling convention of a Git diff driver", func() {
+			from := createTestFile("foo: bar")
+			defer os.Remove(from)
+
+			to := createTestFile("foo: BAR")
+			defer os.Remove(to)
+
+			out, err := dyff("git", "config.yml", from, "5716ca5", "100644", to, "4a4d7f1", "100644")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo(`
+± file changed: config.yml
+
+foo
+  ± value change
+    - bar
+    + BAR
+
+`))
+
+			out, err = dyff("git", "config.yml", "/dev/null", ".", ".", to, "4a4d7f1", "100644")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(BeEquivalentTo("\n+ file added: config.yml\n"))
+		})
+	})
+
+	Context("using a baseline of accepted differences", func() {
+		It("should only report and consider differences that are not accepted", func() {
+			from := createTestFile(`{"image": "app:1.0", "replicas": 1}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"image": "app:1.1", "replicas": 3}`)
+			defer os.Remove(to)
+
+			baseline := createTestFile(`---
+accepted:
+- path: /image
+  toRegex: ^app:1\.\d+$
+`)
+			defer os.Remove(baseline)
+
+			out, err := dyff("between", "--omit-header", "--set-exit-code", "--baseline", baseline, from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(BeEquivalentTo(`
+replicas
+  ± value change
+    - 1
+    + 3
+
+`))
+
+			acceptAll := createTestFile(`---
+accepted:
+- path: /image
+- path: /replicas
+  from: "1"
+`)
+			defer os.Remove(acceptAll)
+
+			_, err = dyff("between", "--omit-header", "--set-exit-code", "--baseline", acceptAll, from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+		})
+	})
+
+	Context("watching the inputs for changes", func() {
+		It("should run the comparison again once an input file changes", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(to)
+
+			var runs int
+			defer SetWatchRunHook(func() bool {
+				runs++
+				if runs == 1 {
+					Expect(ioutil.WriteFile(to, []byte(`{"foo": "BAR"}`), os.FileMode(0644))).To(Succeed())
+				}
+
+				return runs == 2
+			})()
+
+			type result struct {
+				out string
+				err error
+			}
+
+			results := make(chan result, 1)
+			go func() {
+				defer GinkgoRecover()
+				out, err := dyff("between", "--omit-header", "--watch", from, to)
+				results <- result{out, err}
+			}()
+
+			var r result
+			Eventually(results, 10*time.Second).Should(Receive(&r))
+			Expect(r.err).ToNot(HaveOccurred())
+			Expect(runs).To(Equal(2))
+			Expect(strings.Count(r.out, "for changes, last run at")).To(Equal(2))
+			Expect(r.out).To(ContainSubstring(`
+foo
+  ± value change
+    - bar
+    + BAR
+`))
+			Expect(r.out).To(ContainSubstring("since previous run:"))
+		})
+
+		It("should only show the written differences and file changes since the previous run", func() {
+			from := createTestDirectory()
+			defer os.RemoveAll(from)
+
+			to := createTestDirectory()
+			defer os.RemoveAll(to)
+
+			for dir, files := range map[string]map[string]string{
+				from: {"a.yml": "foo: bar", "b.yml": "x: 1"},
+				to:   {"a.yml": "foo: BAR", "b.yml": "x: 2"},
+			} {
+				for name, content := range files {
+					Expect(ioutil.WriteFile(filepath.Join(dir, name), []byte(content), os.FileMode(0644))).To(Succeed())
+				}
+			}
+
+			baseline := createTestFile("accepted:\n- path: /x\n")
+			defer os.Remove(baseline)
+
+			var runs int
+			defer SetWatchRunHook(func() bool {
+				runs++
+				if runs == 1 {
+					Expect(ioutil.WriteFile(filepath.Join(to, "b.yml"), []byte("x: 3"), os.FileMode(0644))).To(Succeed())
+					Expect(ioutil.WriteFile(filepath.Join(to, "new.yml"), []byte("foo: bar"), os.FileMode(0644))).To(Succeed())
+				}
+
+				return runs == 2
+			})()
+
+			results := make(chan string, 1)
+			go func() {
+				defer GinkgoRecover()
+				out, err := dyff("between", "--omit-header", "--baseline", baseline, "--watch", from, to)
+				Expect(err).ToNot(HaveOccurred())
+				results <- out
+			}()
+
+			var out string
+			Eventually(results, 10*time.Second).Should(Receive(&out))
+			Expect(out).To(ContainSubstring("since previous run: one new difference, no resolved differences, and no changed differences\n"))
+			Expect(out).To(MatchRegexp(`new .*\(new\.yml\)`))
+		})
+
+		It("should reject inputs that cannot be watched", func() {
+			from := createTestFile(`{"foo": "bar"}`)
+			defer os.Remove(from)
+
+			defer SetWatchRunHook(func() bool {
+				Fail("the watch mode should not have started")
+				return true
+			})()
+
+			for _, args := range [][]string{
+				{"between", "--watch", from, "-"},
+				{"between", "--watch", "https://example.org/does-not-exist.yml", from},
+			} {
+				_, err := dyff(args...)
+				Expect(err).To(HaveOccurred())
+				Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("only supported for local files and directories"))
+			}
+
+			dir := createTestDirectory()
+			defer os.RemoveAll(dir)
+
+			defer setenv("KUBECTL_EXTERNAL_DIFF", "cmd.test between")()
+			_, err := dyff("between", "--watch", dir, dir)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("not supported when used by kubectl diff"))
+		})
+	})
+
+	Context("using a policy to classify differences", func() {
+		It("should sort differences by severity and set the exit code based on the highest severity", func() {
+			from := createTestFile(`{"image": "app:1.0", "replicas": 3}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"image": "app:1.1", "replicas": 2}`)
+			defer os.Remove(to)
+
+			policy := createTestFile(`---
+rules:
+- path: /image
+  severity: info
+- path: /replicas
+  change: decrease
+  severity: warning
+`)
+			defer os.Remove(policy)
+
+			out, err := dyff("between", "--omit-header", "--set-exit-code", "--policy", policy, from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(2))
+			Expect(out).To(BeEquivalentTo(`
+replicas  [warning]
+  ± value change
+    - 3
+    + 2
+
+image  [info]
+  ± value change
+    - app:1.0
+    + app:1.1
+
+`))
+
+			_, err = dyff("between", "--omit-header", "--set-exit-code", "--policy", policy, to, from)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+		})
+
+		It("should reject change kind based exit code settings together with a policy", func() {
+			from := createTestFile(`{"replicas": 3}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"replicas": 2}`)
+			defer os.Remove(to)
+
+			policy := createTestFile(`{"rules": [{"path": "/replicas", "severity": "warning"}]}`)
+			defer os.Remove(policy)
+
+			for _, args := range [][]string{
+				{"--fail-on", "modification"},
+				{"--exit-code-mode", "kinds"},
+			} {
+				out, err := dyff(append([]string{"between", "--set-exit-code", "--policy", policy, from, to}, args...)...)
+				Expect(err).To(HaveOccurred())
+				Expect(err.(ExitCode).Value).To(Equal(255))
+				Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("cannot be used together with --policy"))
+				Expect(out).To(BeEmpty())
+			}
+
+			_, err := dyff("between", "--set-exit-code", "--exit-code-mode", "any", "--policy", policy, from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(2))
+		})
+	})
+
+	Context("checking the exit code settings", func() {
+		It("should reject invalid exit code settings before anything is compared", func() {
+			for _, args := range [][]string{
+				{"--exit-code-mode", "unknown"},
+				{"--fail-on", "addition,unknown"},
+			} {
+				out, err := dyff(append([]string{"between", "--set-exit-code", "/does/not/exist.yml", "/does/not/exist.yml"}, args...)...)
+				Expect(err).To(HaveOccurred())
+				Expect(err.(ExitCode).Value).To(Equal(255))
+				Expect(err.(ExitCode).Cause.Error()).ToNot(ContainSubstring("failed to load input files"))
+				Expect(out).To(BeEmpty())
+			}
+
+			_, err := dyff("between", "--exit-code-mode", "unknown", "/does/not/exist.yml", "/does/not/exist.yml")
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Cause.Error()).To(ContainSubstring("unknown exit code mode unknown"))
+		})
+	})
+
+	Context("using exit codes per change kind", func() {
+		It("should set the exit code to the bitmask of the change kinds", func() {
+			from := createTestFile(`{"list": [1, 2, 3], "value": "foo", "removed": true}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"list": [3, 2, 1], "value": "bar"}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--set-exit-code", "--exit-code-mode", "kinds", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(2 | 4 | 8))
+
+			_, err = dyff("between", "--set-exit-code", "--exit-code-mode", "kinds", "--fail-on", "order-change", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(8))
+		})
+
+		It("should only fail on the selected change kinds", func() {
+			from := createTestFile(`{"list": [1, 2, 3]}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"list": [3, 2, 1]}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--set-exit-code", "--fail-on", "addition,removal,modification", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+
+			_, err = dyff("between", "--set-exit-code", "--fail-on", "order-change", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+		})
+	})
+
+	Context("using report styles", func() {
+		It("should list the registered report styles and their options in the usage", func() {
+			out, err := dyff("between", "--help")
+			Expect(err).ToNot(HaveOccurred())
+			Expect(out).To(ContainSubstring("supported styles: human, brief, stats, or json"))
+			Expect(out).To(ContainSubstring("minor-change-threshold (human style, default 0.1)"))
+		})
+
+		It("should fail for options that the report style does not support", func() {
+			from := createTestFile(`{"a": 1}`)
+			defer os.Remove(from)
+
+			to := createTestFile(`{"a": 2}`)
+			defer os.Remove(to)
+
+			_, err := dyff("between", "--output", "brief", "--style-option", "minor-change-threshold=0.5", from, to)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("output style brief does not support option minor-change-threshold"))
+
+			_, err = dyff("between", "--omit-header", "--style-option", "minor-change-threshold=0.5", from, to)
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("report-diff command", func() {
+		It("should list new, resolved, and changed differences of two reports", func() {
+			base := createTestFile(`{"a": 1, "b": 2, "c": 3}`)
+			defer os.Remove(base)
+
+			yesterday := createTestFile(`{"a": 1, "b": 20, "c": 30}`)
+			defer os.Remove(yesterday)
+
+			today := createTestFile(`{"a": 10, "b": 2, "c": 300}`)
+			defer os.Remove(today)
+
+			writeReport := func(to string) string {
+				out, err := dyff("between", "--output", "json", base, to)
+				Expect(err).ToNot(HaveOccurred())
+				return createTestFile(out)
+			}
+
+			previous := writeReport(yesterday)
+			defer os.Remove(previous)
+
+			current := writeReport(today)
+			defer os.Remove(current)
+
+			out, err := dyff("report-diff", "--omit-header", "--set-exit-code", previous, current)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(1))
+			Expect(out).To(BeEquivalentTo(`one new difference, one resolved difference, and one changed difference
+  + new       a
+  - resolved  b
+  ± changed   c
+
+new differences:
+
+a
+  ± value change
+    - 1
+    + 10
+
+
+changed differences:
+
+c
+  ± value change
+    - 3
+    + 300
+
+`))
+
+			out, err = dyff("report-diff", "--omit-header", "--set-exit-code", current, current)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(BeEquivalentTo("no new differences, no resolved differences, and no changed differences\n"))
+		})
+
+		It("should only consider the filtered differences and reject flags of comparisons", func() {
+			base := createTestFile(`{"a": 1, "b": 2}`)
+			defer os.Remove(base)
+
+			yesterday := createTestFile(`{"a": 1, "b": 20}`)
+			defer os.Remove(yesterday)
+
+			today := createTestFile(`{"a": 10, "b": 20}`)
+			defer os.Remove(today)
+
+			writeReport := func(to string) string {
+				out, err := dyff("between", "--output", "json", base, to)
+				Expect(err).ToNot(HaveOccurred())
+				return createTestFile(out)
+			}
+
+			previous := writeReport(yesterday)
+			defer os.Remove(previous)
+
+			current := writeReport(today)
+			defer os.Remove(current)
+
+			out, err := dyff("report-diff", "--omit-header", "--set-exit-code", "--filter", "/b", previous, current)
+			Expect(err).To(HaveOccurred())
+			Expect(err.(ExitCode).Value).To(Equal(0))
+			Expect(out).To(BeEquivalentTo("no new differences, no resolved differences, and no changed differences\n"))
+
+			for _, flag := range []string{"--baseline", "--policy", "--fail-on", "--exit-code-mode", "--include", "--exclude", "--workers", "--normalize-defaults"} {
+				_, err = dyff("report-diff", flag+"=1", previous, current)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("unknown flag: " + flag))
+			}
+		})
+	})
 })
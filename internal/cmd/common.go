@@ -45,12 +45,27 @@ type reportConfig struct {
 	style                     string
 	ignoreOrderChanges        bool
 	kubernetesEntityDetection bool
+	normalizeDefaults         bool
+	defaultsFile              string
 	noTableStyle              bool
 	doNotInspectCerts         bool
 	exitWithCode              bool
 	omitHeader                bool
 	useGoPatchPaths           bool
+	include                   []string
+	exclude                   []string
+	maxValueLines             int
+	maxValueBytes             int
+	contextLines              int
+	noPager                   bool
+	showStats                 bool
 	filters                   []string
+	baseline                  string
+	policy                    string
+	exitCodeMode              string
+	failOn                    []string
+	workers                   int
+	styleOptions              []string
 }
 
 var reportOptions reportConfig
@@ -59,23 +74,97 @@ func applyReportOptionsFlags(cmd *cobra.Command) {
 	// Compare options
 	cmd.Flags().BoolVarP(&reportOptions.ignoreOrderChanges, "ignore-order-changes", "i", false, "ignore order changes in lists")
 	cmd.Flags().BoolVarP(&reportOptions.kubernetesEntityDetection, "detect-kubernetes", "", false, "detect kubernetes entities")
+	cmd.Flags().BoolVar(&reportOptions.normalizeDefaults, "normalize-defaults", false, "ignore fields of Kubernetes resources that are set to the default value of the API server")
+	cmd.Flags().StringVar(&reportOptions.defaultsFile, "defaults-file", "", "load additional default value rules from file, implies --normalize-defaults")
 	cmd.Flags().StringSliceVar(&reportOptions.filters, "filter", nil, "filter reports to a subset of differences based on supplied arguments")
+	cmd.Flags().IntVar(&reportOptions.workers, "workers", 1, "number of workers that compare documents and large subtrees concurrently")
+	cmd.Flags().StringVar(&reportOptions.baseline, "baseline", "", "hide the accepted differences listed in the baseline file, and only consider the other differences for the exit code")
+	cmd.Flags().StringVar(&reportOptions.policy, "policy", "", "classify the differences by severity based on the rules in the policy file, which also sets distinct exit codes for warnings (2) and errors (3), cannot be combined with --fail-on or --exit-code-mode kinds")
+	cmd.Flags().StringSliceVar(&reportOptions.include, "include", nil, "when comparing directories or Git revisions, only include files matching the glob patterns (relative path or file name)")
+	cmd.Flags().StringSliceVar(&reportOptions.exclude, "exclude", nil, "when comparing directories or Git revisions, exclude files matching the glob patterns (relative path or file name)")
 
+	applyOutputOptionsFlags(cmd, true)
+
+	// The exit code settings are checked before anything is compared
+	cmd.PreRunE = func(*cobra.Command, []string) error {
+		return checkExitCodeSettings()
+	}
+}
+
+// applyOutputOptionsFlags adds the flags for the output of a report, which
+// optionally includes the flags that configure the exit code by change kind
+func applyOutputOptionsFlags(cmd *cobra.Command, exitCodeKinds bool) {
 	// Main output preferences
-	cmd.Flags().StringVarP(&reportOptions.style, "output", "o", defaultOutputStyle, "specify the output style, supported styles: human, or brief")
+	cmd.Flags().StringVarP(&reportOptions.style, "output", "o", defaultOutputStyle, outputStyleUsage())
+	cmd.Flags().StringSliceVar(&reportOptions.styleOptions, "style-option", nil, styleOptionUsage())
+	cmd.Flags().BoolVar(&reportOptions.showStats, "stats", false, "add statistics of the differences by change kind, top-level key, and Kubernetes resource to the report")
 	cmd.Flags().BoolVarP(&reportOptions.omitHeader, "omit-header", "b", false, "omit the dyff summary header")
 	cmd.Flags().BoolVarP(&reportOptions.exitWithCode, "set-exit-code", "s", false, "set program exit code, with 0 meaning no difference, 1 for differences detected, and 255 for program error")
+	if exitCodeKinds {
+		cmd.Flags().StringVar(&reportOptions.exitCodeMode, "exit-code-mode", "any", "exit code mode of --set-exit-code, either any (1 for differences), or kinds (bitmask of the change kinds: addition 1, removal 2, modification 4, order-change 8)")
+		cmd.Flags().StringSliceVar(&reportOptions.failOn, "fail-on", nil, "only consider the given change kinds for the exit code: addition, removal, modification, or order-change")
+	}
+
+	cmd.Flags().BoolVar(&reportOptions.noPager, "no-pager", false, "do not page long reports through $DYFF_PAGER or $PAGER (default less -R)")
 
 	// Human/BOSH output related flags
 	cmd.Flags().BoolVarP(&reportOptions.noTableStyle, "no-table-style", "l", false, "do not place blocks next to each other, always use one row per text block")
 	cmd.Flags().BoolVarP(&reportOptions.doNotInspectCerts, "no-cert-inspection", "x", false, "disable x509 certificate inspection, compare as raw text")
 	cmd.Flags().BoolVarP(&reportOptions.useGoPatchPaths, "use-go-patch-style", "g", false, "use Go-Patch style paths in outputs")
+	cmd.Flags().IntVar(&reportOptions.maxValueLines, "max-value-lines", 0, "limit the number of lines shown per value, elide the middle section of longer values (0 for no limit)")
+	cmd.Flags().IntVar(&reportOptions.maxValueBytes, "max-value-bytes", 0, "limit the number of bytes shown per value, elide the middle section of larger values (0 for no limit)")
+	cmd.Flags().IntVar(&reportOptions.contextLines, "context-lines", 0, "only show changed lines of multi-line strings with the given number of unchanged lines around them (0 to show full values)")
 
 	// Deprecated
 	cmd.Flags().BoolVar(&reportOptions.exitWithCode, "set-exit-status", false, "set program exit code, with 0 meaning no difference, 1 for differences detected, and 255 for program error")
 	cmd.Flags().MarkDeprecated("set-exit-status", "use --set-exit-code instead")
 }
 
+// compareOptions returns the compare options based on the configured flags
+func compareOptions() ([]dyff.CompareOption, error) {
+	normalizeDefaults, err := normalizeDefaultsOptions()
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]dyff.CompareOption{
+		dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges),
+		dyff.KubernetesEntityDetection(reportOptions.kubernetesEntityDetection),
+		dyff.Workers(reportOptions.workers),
+	}, normalizeDefaults...), nil
+}
+
+// directoryFilter returns the filter for the files of directories based on
+// the include and exclude patterns, which fails for malformed patterns
+func directoryFilter() (dyff.DirectoryFilter, error) {
+	filter, err := dyff.NewDirectoryFilter(reportOptions.include, reportOptions.exclude)
+	if err != nil {
+		return dyff.DirectoryFilter{}, wrap.Errorf(err, "failed to use the include and exclude patterns")
+	}
+
+	return filter, nil
+}
+
+// normalizeDefaultsOptions returns the compare option to remove Kubernetes
+// default values with the built-in and the user provided rules, if enabled
+func normalizeDefaultsOptions() ([]dyff.CompareOption, error) {
+	if !reportOptions.normalizeDefaults && reportOptions.defaultsFile == "" {
+		return nil, nil
+	}
+
+	rules := dyff.KubernetesDefaults
+	if reportOptions.defaultsFile != "" {
+		additional, err := dyff.LoadDefaultRules(reportOptions.defaultsFile)
+		if err != nil {
+			return nil, wrap.Errorf(err, "failed to load default value rules")
+		}
+
+		rules = append(append([]dyff.DefaultRule{}, rules...), additional...)
+	}
+
+	return []dyff.CompareOption{dyff.NormalizeDefaults(rules)}, nil
+}
+
 // OutputWriter encapsulates the required fields to define the look and feel of
 // the output
 type OutputWriter struct {
@@ -137,7 +226,7 @@ func (w *OutputWriter) write(writer io.Writer, filename string) error {
 
 		switch {
 		case w.PlainMode && w.OutputStyle == "json":
-			output, err := neat.NewOutputProcessor(false, false, &neat.DefaultColorSchema).ToCompactJSON(document)
+			output, err := neat.NewOutputProcessor(false, false, dyff.DocumentColorSchema()).ToCompactJSON(document)
 			if err != nil {
 				return err
 			}
@@ -151,18 +240,18 @@ func (w *OutputWriter) write(writer io.Writer, filename string) error {
 			encoder.Close()
 
 		case w.OutputStyle == "json":
-			output, err := neat.NewOutputProcessor(!w.OmitIndentHelper, true, &neat.DefaultColorSchema).ToJSON(document)
+			output, err := neat.NewOutputProcessor(!w.OmitIndentHelper, true, dyff.DocumentColorSchema()).ToJSON(document)
 			if err != nil {
 				return err
 			}
-			fmt.Fprintf(writer, "%s\n", output)
+			fmt.Fprintf(writer, "%s\n", dyff.ASCIISafe(output))
 
 		case w.OutputStyle == "yaml":
-			output, err := neat.NewOutputProcessor(!w.OmitIndentHelper, true, &neat.DefaultColorSchema).ToYAML(document)
+			output, err := neat.NewOutputProcessor(!w.OmitIndentHelper, true, dyff.DocumentColorSchema()).ToYAML(document)
 			if err != nil {
 				return err
 			}
-			fmt.Fprintf(writer, "%s\n", output)
+			fmt.Fprintf(writer, "%s\n", dyff.ASCIISafe(output))
 		}
 	}
 
@@ -170,44 +259,344 @@ func (w *OutputWriter) write(writer io.Writer, filename string) error {
 }
 
 func writeReport(cmd *cobra.Command, report dyff.Report) error {
-	var reportWriter dyff.ReportWriter
-	switch strings.ToLower(reportOptions.style) {
-	case "human", "bosh":
-		reportWriter = &dyff.HumanReport{
-			Report:               report,
-			DoNotInspectCerts:    reportOptions.doNotInspectCerts,
-			NoTableStyle:         reportOptions.noTableStyle,
-			OmitHeader:           reportOptions.omitHeader,
-			UseGoPatchPaths:      reportOptions.useGoPatchPaths,
-			MinorChangeThreshold: 0.1,
-		}
+	_, err := writeProcessedReport(cmd, report)
+	return err
+}
 
-	case "brief", "short", "summary":
-		reportWriter = &dyff.BriefReport{
-			Report: report,
-		}
+// writeProcessedReport writes the report like writeReport, and returns the
+// report with the baseline and the policy applied, as it was written
+func writeProcessedReport(cmd *cobra.Command, report dyff.Report) (dyff.Report, error) {
+	report, err := applyBaseline(report)
+	if err != nil {
+		return dyff.Report{}, err
+	}
 
-	default:
-		return wrap.Errorf(
+	report, err = applyPolicy(report)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	reportWriter, err := newReportWriter(cmd, report)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	out := newPager(os.Stdout, reportOptions.noPager)
+	if err := reportWriter.WriteReport(out); err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to print report")
+	}
+
+	if err := out.Close(); err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to print report")
+	}
+
+	return report, reportExitCode(report)
+}
+
+// reportExitCode returns the exit code for the differences of the report,
+// based on the severities if a policy is configured, or the change kinds, or
+// just whether there are differences when used by kubectl diff
+func reportExitCode(report dyff.Report) error {
+	// kubectl diff treats any exit code other than 0 (no differences) and 1
+	// (differences) as a failure of the external diff program
+	if betweenCmdSettings.kubectlDiff {
+		return exitCode(len(report.Diffs) > 0)
+	}
+
+	if reportOptions.policy != "" {
+		return severityExitCode(report)
+	}
+
+	return kindExitCode(report)
+}
+
+// writeEmbeddedReport writes a report as one section of a larger report, which
+// is introduced by the provided title instead of the usual report header
+func writeEmbeddedReport(cmd *cobra.Command, out io.Writer, title string, report dyff.Report) error {
+	options := reportWriterOptions()
+	options.OmitHeader = true
+
+	reportWriter, err := newReportWriterWithOptions(cmd, report, options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(out, title)
+	return reportWriter.WriteReport(out)
+}
+
+// newReportWriter creates the report writer for the configured output style
+func newReportWriter(cmd *cobra.Command, report dyff.Report) (dyff.ReportWriter, error) {
+	return newReportWriterWithOptions(cmd, report, reportWriterOptions())
+}
+
+func newReportWriterWithOptions(cmd *cobra.Command, report dyff.Report, options dyff.ReportWriterOptions) (dyff.ReportWriter, error) {
+	settings, err := styleSettings()
+	if err != nil {
+		return nil, err
+	}
+
+	options.Settings = settings
+
+	if _, ok := dyff.LookupReportStyle(reportOptions.style); !ok {
+		return nil, wrap.Errorf(
 			fmt.Errorf(cmd.UsageString()),
 			"unknown output style %s", reportOptions.style,
 		)
 	}
 
-	if err := reportWriter.WriteReport(os.Stdout); err != nil {
-		return wrap.Errorf(err, "failed to print report")
+	reportWriter, err := dyff.NewReportWriter(reportOptions.style, report, options)
+	if err != nil {
+		return nil, wrap.Errorf(err, "failed to create report writer")
+	}
+
+	return reportWriter, nil
+}
+
+// reportWriterOptions returns the report writer options based on the
+// configured flags
+func reportWriterOptions() dyff.ReportWriterOptions {
+	return dyff.ReportWriterOptions{
+		DoNotInspectCerts: reportOptions.doNotInspectCerts,
+		NoTableStyle:      reportOptions.noTableStyle,
+		OmitHeader:        reportOptions.omitHeader,
+		UseGoPatchPaths:   reportOptions.useGoPatchPaths,
+		MaxValueLines:     reportOptions.maxValueLines,
+		MaxValueBytes:     reportOptions.maxValueBytes,
+		ContextLines:      reportOptions.contextLines,
+		ShowStats:         reportOptions.showStats,
+	}
+}
+
+// styleSettings returns the style specific options of the style option flag
+func styleSettings() (map[string]string, error) {
+	settings := map[string]string{}
+	for _, entry := range reportOptions.styleOptions {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			return nil, fmt.Errorf("invalid output style option %s, expected name=value", entry)
+		}
+
+		settings[parts[0]] = parts[1]
+	}
+
+	return settings, nil
+}
+
+// outputStyleUsage returns the usage of the output flag with all registered
+// report styles
+func outputStyleUsage() string {
+	return fmt.Sprintf("specify the output style, supported styles: %s", enumeration(dyff.ReportStyleNames()))
+}
+
+// styleOptionUsage returns the usage of the style option flag with the
+// options of all registered report styles
+func styleOptionUsage() string {
+	var options []string
+	for _, style := range dyff.ReportStyles() {
+		for _, option := range style.Options {
+			options = append(options, fmt.Sprintf("%s (%s style, default %s)", option.Name, style.Name, option.Default))
+		}
+	}
+
+	if len(options) == 0 {
+		return "set options of the output style, using name=value"
+	}
+
+	return fmt.Sprintf("set options of the output style, using name=value, supported options: %s", enumeration(options))
+}
+
+// enumeration joins the entries as a human readable list, e.g. "a, b, or c"
+func enumeration(entries []string) string {
+	switch len(entries) {
+	case 0:
+		return ""
+
+	case 1:
+		return entries[0]
+	}
+
+	return strings.Join(entries[:len(entries)-1], ", ") + ", or " + entries[len(entries)-1]
+}
+
+// applyFilters reduces the report to the differences of the configured
+// filter paths, if there are any
+func applyFilters(report dyff.Report) (dyff.Report, error) {
+	if reportOptions.filters == nil {
+		return report, nil
+	}
+
+	var filterPaths []ytbx.Path
+	for _, pathString := range reportOptions.filters {
+		path, err := ytbx.ParsePathStringUnsafe(pathString)
+		if err != nil {
+			return dyff.Report{}, wrap.Errorf(err, "failed to set path filter, because path %s cannot be parsed", pathString)
+		}
+
+		filterPaths = append(filterPaths, path)
+	}
+
+	return report.Filter(filterPaths...), nil
+}
+
+// applyBaseline removes the differences that are accepted in the configured
+// baseline file from the report, and warns about baseline entries that no
+// longer match any difference
+func applyBaseline(report dyff.Report) (dyff.Report, error) {
+	report, stale, err := applyBaselineEntries(report)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	for _, entry := range stale {
+		warnStaleBaselineEntry(entry.String())
+	}
+
+	return report, nil
+}
+
+// applyBaselineEntries removes the differences that are accepted in the
+// configured baseline file from the report, and returns the baseline entries
+// that do not match any difference
+func applyBaselineEntries(report dyff.Report) (dyff.Report, []dyff.BaselineEntry, error) {
+	if reportOptions.baseline == "" {
+		return report, nil, nil
+	}
+
+	baseline, err := dyff.LoadBaseline(reportOptions.baseline)
+	if err != nil {
+		return dyff.Report{}, nil, wrap.Errorf(err, "failed to load baseline")
+	}
+
+	report, stale := report.ApplyBaseline(baseline)
+	return report, stale, nil
+}
+
+// warnStaleBaselineEntry warns about a baseline entry that no longer matches
+// any difference
+func warnStaleBaselineEntry(entry string) {
+	fmt.Fprint(os.Stderr, bunt.Sprintf("stale baseline entry, difference does not occur anymore: _*%s*_\n", entry))
+}
+
+// applyPolicy classifies the differences of the report by severity using the
+// rules of the configured policy file
+func applyPolicy(report dyff.Report) (dyff.Report, error) {
+	if reportOptions.policy == "" {
+		return report, nil
+	}
+
+	policy, err := dyff.LoadPolicy(reportOptions.policy)
+	if err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to load policy")
 	}
 
+	return report.Classify(policy), nil
+}
+
+// exitCode returns the exit code based on whether differences were found, if
+// the respective flag is set, otherwise nil
+func exitCode(differences bool) error {
 	// If configured, make sure `dyff` exists with an exit status
 	if reportOptions.exitWithCode {
-		switch len(report.Diffs) {
-		case 0:
-			return ExitCode{Value: 0}
-
-		default:
+		if differences {
 			return ExitCode{Value: 1}
 		}
+
+		return ExitCode{Value: 0}
+	}
+
+	return nil
+}
+
+// checkExitCodeSettings makes sure that the exit code mode and the change
+// kinds to fail on are valid, and that the exit code is either derived from
+// the severities of a policy, or from the change kinds, since their exit codes
+// overlap. It runs before anything is compared.
+func checkExitCodeSettings() error {
+	if _, err := failOnMask(); err != nil {
+		return err
+	}
+
+	switch strings.ToLower(reportOptions.exitCodeMode) {
+	case "", "any", "kinds":
+
+	default:
+		return fmt.Errorf("unknown exit code mode %s, supported modes are: any, or kinds", reportOptions.exitCodeMode)
+	}
+
+	if reportOptions.policy == "" {
+		return nil
+	}
+
+	if len(reportOptions.failOn) > 0 {
+		return fmt.Errorf("--fail-on cannot be used together with --policy, which derives the exit code from the severities")
+	}
+
+	if mode := strings.ToLower(reportOptions.exitCodeMode); mode != "" && mode != "any" {
+		return fmt.Errorf("--exit-code-mode %s cannot be used together with --policy, which derives the exit code from the severities", reportOptions.exitCodeMode)
 	}
 
 	return nil
 }
+
+// failOnMask returns the bitmask of the change kinds to fail on, or -1 in
+// case all change kinds are considered
+func failOnMask() (int, error) {
+	if len(reportOptions.failOn) == 0 {
+		return -1, nil
+	}
+
+	var mask int
+	for _, name := range reportOptions.failOn {
+		kind, err := dyff.KindByName(strings.ToLower(name))
+		if err != nil {
+			return 0, wrap.Errorf(err, "failed to set change kinds to fail on")
+		}
+
+		mask |= dyff.KindBit(kind)
+	}
+
+	return mask, nil
+}
+
+// severityExitCode returns the exit code based on the highest severity of the
+// differences, if the respective flag is set, otherwise nil: 0 meaning no
+// difference, 1 for differences without warnings or errors, 2 for warnings,
+// and 3 for errors
+func severityExitCode(report dyff.Report) error {
+	if !reportOptions.exitWithCode {
+		return nil
+	}
+
+	switch report.MaxSeverity() {
+	case dyff.SeverityError:
+		return ExitCode{Value: 3}
+
+	case dyff.SeverityWarning:
+		return ExitCode{Value: 2}
+	}
+
+	return exitCode(len(report.Diffs) > 0)
+}
+
+// kindExitCode returns the exit code based on the change kinds of the report,
+// if the respective flag is set, otherwise nil: Only the change kinds listed
+// in the fail-on flag are considered, and depending on the exit code mode the
+// exit code is either 1 for differences, or the bitmask of the change kinds
+func kindExitCode(report dyff.Report) error {
+	if !reportOptions.exitWithCode {
+		return nil
+	}
+
+	mask, err := failOnMask()
+	if err != nil {
+		return err
+	}
+
+	failOn := report.KindMask() & mask
+	if strings.ToLower(reportOptions.exitCodeMode) == "kinds" {
+		return ExitCode{Value: failOn}
+	}
+
+	return exitCode(failOn != 0)
+}
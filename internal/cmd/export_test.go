@@ -0,0 +1,37 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+// SetIsTerminal replaces the check whether the output is a terminal, and
+// returns a function to restore the original check
+func SetIsTerminal(check func() bool) func() {
+	tmp := isTerminal
+	isTerminal = check
+	return func() { isTerminal = tmp }
+}
+
+// SetWatchRunHook sets the function that is called after every run of the
+// watch mode, and returns a function to restore the original hook
+func SetWatchRunHook(hook func() bool) func() {
+	tmp := watchRunHook
+	watchRunHook = hook
+	return func() { watchRunHook = tmp }
+}
@@ -0,0 +1,336 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/wrap"
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// nullFile is used by Git as the file name for the missing side of a file
+// that was added or removed
+const nullFile = "/dev/null"
+
+// gitCmd represents the git command
+var gitCmd = &cobra.Command{
+	Use:   "git [flags] <from-revision> [<to-revision>] -- <path>...",
+	Short: "Compare files across Git revisions, or use as a Git diff driver",
+	Long: `
+Compares files of the local Git repository between two revisions. In case only
+one revision is provided, the files of that revision are compared against the
+files in the working tree. Paths can be files or directories.
+
+Example: dyff git HEAD~1 HEAD -- deployment.yml
+
+To use dyff for all YAML files with 'git diff', register it as a diff driver,
+which is then called by Git with seven arguments per changed file:
+
+  git config diff.dyff.command 'dyff git'
+  echo '*.yml diff=dyff' >> .gitattributes
+`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() < 0 && len(args) == 7 {
+			return nil
+		}
+
+		_, _, _, err := gitArguments(cmd, args)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if cmd.ArgsLenAtDash() < 0 && len(args) == 7 {
+			return gitDiffDriver(cmd, args)
+		}
+
+		from, to, paths, _ := gitArguments(cmd, args)
+
+		root, err := git("rev-parse", "--show-toplevel")
+		if err != nil {
+			return wrap.Errorf(err, "failed to find Git repository")
+		}
+
+		report, err := compareGitRevisions(strings.TrimSpace(string(root)), from, to, paths)
+		if err != nil {
+			return err
+		}
+
+		return writeDirectoryReport(cmd, report)
+	},
+}
+
+// gitArguments splits the arguments into the from and to revision, and the
+// paths, where an empty to revision refers to the working tree
+func gitArguments(cmd *cobra.Command, args []string) (string, string, []string, error) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		return "", "", nil, fmt.Errorf("requires a double dash to separate the revisions from the paths")
+	}
+
+	if dash == len(args) {
+		return "", "", nil, fmt.Errorf("requires at least one path")
+	}
+
+	switch dash {
+	case 1:
+		return args[0], "", args[dash:], nil
+
+	case 2:
+		return args[0], args[1], args[dash:], nil
+
+	default:
+		return "", "", nil, fmt.Errorf("requires one or two revisions")
+	}
+}
+
+// gitDiffDriver compares the files provided by Git when it calls an external
+// diff command: path old-file old-hex old-mode new-file new-hex new-mode
+func gitDiffDriver(cmd *cobra.Command, args []string) error {
+	path, oldFile, newFile := args[0], args[1], args[4]
+
+	file := dyff.FileReport{Path: path}
+	switch {
+	case oldFile == nullFile:
+		file.Kind = dyff.ADDITION
+
+	case newFile == nullFile:
+		file.Kind = dyff.REMOVAL
+
+	default:
+		from, to, err := ytbx.LoadFiles(oldFile, newFile)
+		if err != nil {
+			return wrap.Errorf(err, "failed to load input files")
+		}
+
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		file.Kind = dyff.MODIFICATION
+		if file.Report, err = dyff.CompareInputFiles(from, to, options...); err != nil {
+			return wrap.Errorf(err, "failed to compare input files")
+		}
+	}
+
+	// Git shows the output of each file one after another, and treats any
+	// exit code other than zero as a failure of the diff command
+	reportOptions.omitHeader = true
+	reportOptions.exitWithCode = false
+
+	return writeDirectoryReport(cmd, dyff.DirectoryReport{
+		From:  "a/" + path,
+		To:    "b/" + path,
+		Files: []dyff.FileReport{file},
+	})
+}
+
+// compareGitRevisions compares the YAML and JSON files in the paths of the
+// from revision with the files of the to revision, or the working tree if it
+// is empty, where the include and exclude patterns work like for directories
+func compareGitRevisions(root string, from string, to string, paths []string) (dyff.DirectoryReport, error) {
+	filter, err := directoryFilter()
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	fromFiles, err := gitFiles(root, from, paths, filter)
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	toFiles, err := gitFiles(root, to, paths, filter)
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	if len(fromFiles) == 0 && len(toFiles) == 0 {
+		return dyff.DirectoryReport{}, fmt.Errorf("no YAML or JSON files found in %s", strings.Join(paths, ", "))
+	}
+
+	names := make([]string, 0, len(fromFiles)+len(toFiles))
+	for name := range fromFiles {
+		names = append(names, name)
+	}
+
+	for name := range toFiles {
+		if _, ok := fromFiles[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	options, err := compareOptions()
+	if err != nil {
+		return dyff.DirectoryReport{}, err
+	}
+
+	result := dyff.DirectoryReport{From: from, To: to}
+	if result.To == "" {
+		result.To = "working tree"
+	}
+
+	for _, name := range names {
+		_, inFrom := fromFiles[name]
+		_, inTo := toFiles[name]
+
+		switch {
+		case inFrom && !inTo:
+			result.Files = append(result.Files, dyff.FileReport{Path: name, Kind: dyff.REMOVAL})
+
+		case !inFrom && inTo:
+			result.Files = append(result.Files, dyff.FileReport{Path: name, Kind: dyff.ADDITION})
+
+		default:
+			result.Files = append(result.Files, compareGitFile(root, from, to, name, options))
+		}
+	}
+
+	return result, nil
+}
+
+// compareGitFile compares the file of both revisions, where errors are
+// recorded for the file, so that the other files are still compared
+func compareGitFile(root string, from string, to string, name string, options []dyff.CompareOption) dyff.FileReport {
+	result := dyff.FileReport{Path: name, Kind: dyff.MODIFICATION}
+
+	fromFile, err := gitInputFile(root, from, name)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	toFile, err := gitInputFile(root, to, name)
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	if result.Report, err = dyff.CompareInputFiles(fromFile, toFile, options...); err != nil {
+		result.Err = wrap.Errorf(err, "failed to compare %s", name)
+	}
+
+	return result
+}
+
+// gitFiles returns the set of YAML and JSON files (relative to the repository
+// root) in the paths of the revision that match the filter, or the existing
+// files that are not ignored in the working tree in case the revision is empty
+func gitFiles(root string, revision string, paths []string, filter dyff.DirectoryFilter) (map[string]struct{}, error) {
+	args := append([]string{"ls-files", "--full-name", "--cached", "--others", "--exclude-standard", "--"}, paths...)
+	if revision != "" {
+		args = append([]string{"ls-tree", "-r", "--name-only", "--full-name", revision, "--"}, paths...)
+	}
+
+	output, err := git(args...)
+	if err != nil {
+		return nil, wrap.Errorf(err, "failed to list files of %s", gitRevisionName(revision))
+	}
+
+	result := map[string]struct{}{}
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line == "" || !dyff.IsDocumentFile(line) || !filter.Matches(line) {
+			continue
+		}
+
+		if revision == "" {
+			if _, err := os.Stat(filepath.Join(root, line)); err != nil {
+				continue
+			}
+		}
+
+		result[line] = struct{}{}
+	}
+
+	return result, nil
+}
+
+// gitInputFile loads the file of the revision, or the working tree in case the
+// revision is empty
+func gitInputFile(root string, revision string, name string) (ytbx.InputFile, error) {
+	var data []byte
+	var err error
+	if revision == "" {
+		data, err = ioutil.ReadFile(filepath.Join(root, name))
+	} else {
+		data, err = git("show", revision+":"+name)
+	}
+
+	if err != nil {
+		return ytbx.InputFile{}, wrap.Errorf(err, "failed to load %s from %s", name, gitRevisionName(revision))
+	}
+
+	result := ytbx.InputFile{Location: gitRevisionName(revision) + ":" + name}
+	if len(bytes.TrimSpace(data)) == 0 {
+		return result, nil
+	}
+
+	if result.Documents, err = ytbx.LoadDocuments(data); err != nil {
+		return ytbx.InputFile{}, wrap.Errorf(err, "failed to parse %s", result.Location)
+	}
+
+	return result, nil
+}
+
+func gitRevisionName(revision string) string {
+	if revision == "" {
+		return "working tree"
+	}
+
+	return revision
+}
+
+func git(args ...string) ([]byte, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("git", args...)
+	cmd.Stderr = &stderr
+
+	output, err := cmd.Output()
+	if err != nil {
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%s", message)
+		}
+
+		return nil, err
+	}
+
+	return output, nil
+}
+
+func init() {
+	rootCmd.AddCommand(gitCmd)
+
+	gitCmd.Flags().SortFlags = false
+	gitCmd.PersistentFlags().SortFlags = false
+
+	applyReportOptionsFlags(gitCmd)
+}
@@ -22,7 +22,9 @@ package cmd
 
 import (
 	"fmt"
+	"strings"
 
+	"github.com/gonvenience/text"
 	"github.com/gonvenience/wrap"
 	"github.com/gonvenience/ytbx"
 	"github.com/spf13/cobra"
@@ -31,6 +33,12 @@ import (
 	"github.com/homeport/dyff/pkg/dyff"
 )
 
+type lastAppliedCmdOptions struct {
+	fieldManager string
+}
+
+var lastAppliedCmdSettings lastAppliedCmdOptions
+
 // lastAppliedCmd represents the lastApplied command
 var lastAppliedCmd = &cobra.Command{
 	Use:   "last-applied",
@@ -39,6 +47,17 @@ var lastAppliedCmd = &cobra.Command{
 Kubernetes resource YAML (or JSON) contain the previously used configuration of
 that resource in the metadata. For convenience, the respective metadata is used
 to compare it against the current configuration.
+
+Resources that were applied using client-side apply store the configuration in
+the kubectl.kubernetes.io/last-applied-configuration annotation. For resources
+that were applied using server-side apply, the configuration is reconstructed
+from the fields owned by the field manager in the managed fields. In case the
+annotation is not available, or a field manager is selected explicitly, the
+managed fields are used.
+
+Input files with multiple documents, or a Kubernetes List, are compared resource
+by resource and shown in one combined report. Resources without a last applied
+configuration are skipped.
 `,
 	Args:    cobra.ExactArgs(1),
 	Aliases: []string{"la"},
@@ -48,24 +67,108 @@ to compare it against the current configuration.
 			return err
 		}
 
-		if len(inputFile.Documents) != 1 {
-			return fmt.Errorf("failed to compare, because the input contains more than one document")
+		if len(inputFile.Documents) == 1 && !dyff.IsKubernetesList(inputFile.Documents[0]) {
+			lastConfiguration, err := lookUpLastAppliedConfiguration(inputFile)
+			if err != nil {
+				return err
+			}
+
+			report, err := compareLastApplied(lastConfiguration, inputFile)
+			if err != nil {
+				return err
+			}
+
+			return writeReport(cmd, report)
 		}
 
-		lastConfiguration, err := lookUpLastAppliedConfiguration(inputFile)
+		report, err := compareResourcesLastApplied(inputFile)
 		if err != nil {
 			return err
 		}
 
+		return writeReport(cmd, report)
+	},
+}
+
+// compareLastApplied compares the last applied configuration with the current
+// state of the resource, ignoring the fields that are not part of the applied
+// configuration by design
+func compareLastApplied(lastConfiguration ytbx.InputFile, inputFile ytbx.InputFile) (dyff.Report, error) {
+	if lastConfiguration.Location == lastAppliedAnnotationLocation {
 		purgeWellKnownMetadataEntries(inputFile.Documents[0])
+	} else {
+		dyff.StripServerManagedFields(lastConfiguration.Documents[0])
+		dyff.StripServerManagedFields(inputFile.Documents[0])
+	}
+
+	normalizeDefaults, err := normalizeDefaultsOptions()
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	report, err := dyff.CompareInputFiles(lastConfiguration, inputFile, append([]dyff.CompareOption{dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges)}, normalizeDefaults...)...)
+	if err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to compare input files")
+	}
+
+	return report, nil
+}
 
-		report, err := dyff.CompareInputFiles(lastConfiguration, inputFile, dyff.IgnoreOrderChanges(reportOptions.ignoreOrderChanges))
+// compareResourcesLastApplied compares each resource of an input file with
+// multiple documents, or a Kubernetes List, against its own last applied
+// configuration, and combines the results into one report with one document
+// per resource. Resources without a last applied configuration are skipped.
+func compareResourcesLastApplied(inputFile ytbx.InputFile) (dyff.Report, error) {
+	resources := dyff.InputFileResources(inputFile)
+	if len(resources) == 0 {
+		return dyff.Report{}, fmt.Errorf("failed to compare, because the input does not contain any Kubernetes resources")
+	}
+
+	result := dyff.Report{
+		From: ytbx.InputFile{Location: inputFile.Location, Note: "last applied configuration"},
+		To:   ytbx.InputFile{Location: inputFile.Location},
+	}
+
+	var skipped []string
+	for _, resource := range resources {
+		current := ytbx.InputFile{Location: resource.Location, Documents: []*yamlv3.Node{resource.Document}}
+
+		lastConfiguration, err := lookUpLastAppliedConfiguration(current)
 		if err != nil {
-			return wrap.Errorf(err, "failed to compare input files")
+			skipped = append(skipped, resource.ID.String())
+			continue
 		}
 
-		return writeReport(cmd, report)
-	},
+		report, err := compareLastApplied(lastConfiguration, current)
+		if err != nil {
+			return dyff.Report{}, wrap.Errorf(err, "failed to compare %s", resource.ID)
+		}
+
+		idx := len(result.From.Documents)
+		result.From.Documents = append(result.From.Documents, lastConfiguration.Documents[0])
+		result.From.Names = append(result.From.Names, resource.ID.String())
+		result.To.Documents = append(result.To.Documents, resource.Document)
+		result.To.Names = append(result.To.Names, resource.ID.String())
+
+		for _, diff := range report.Diffs {
+			diff.Path.Root = &result.From
+			diff.Path.DocumentIdx = idx
+			result.Diffs = append(result.Diffs, diff)
+		}
+	}
+
+	if len(result.From.Documents) == 0 {
+		return dyff.Report{}, fmt.Errorf("failed to compare, because none of the resources contain the last applied configuration metadata, nor managed fields of a server-side apply")
+	}
+
+	if len(skipped) > 0 {
+		result.To.Note = fmt.Sprintf("skipped %s without last applied configuration: %s",
+			text.Plural(len(skipped), "resource"),
+			strings.Join(skipped, ", "),
+		)
+	}
+
+	return result, nil
 }
 
 func init() {
@@ -75,12 +178,27 @@ func init() {
 	lastAppliedCmd.PersistentFlags().SortFlags = false
 
 	applyReportOptionsFlags(lastAppliedCmd)
+
+	lastAppliedCmd.Flags().StringVar(&lastAppliedCmdSettings.fieldManager, "field-manager", "", "use the fields owned by this field manager (server-side apply) instead of the last applied configuration annotation")
 }
 
+const lastAppliedAnnotationLocation = "/metadata/annotations/kubectl.kubernetes.io/last-applied-configuration"
+
+// lookUpLastAppliedConfiguration returns the last applied configuration from
+// the respective annotation, or falls back to reconstruct it from the managed
+// fields of a server-side apply
 func lookUpLastAppliedConfiguration(inputFile ytbx.InputFile) (ytbx.InputFile, error) {
+	if lastAppliedCmdSettings.fieldManager != "" {
+		return lookUpServerSideAppliedConfiguration(inputFile, lastAppliedCmdSettings.fieldManager)
+	}
+
 	kubectlLastApplied, err := ytbx.Grab(inputFile.Documents[0], "/metadata/annotations/kubectl.kubernetes.io\\/last-applied-configuration")
 	if err != nil {
-		return ytbx.InputFile{}, fmt.Errorf("provided input file does not contain the last applied configuration metadata")
+		if len(dyff.FieldManagers(inputFile.Documents[0])) > 0 {
+			return lookUpServerSideAppliedConfiguration(inputFile, "")
+		}
+
+		return ytbx.InputFile{}, fmt.Errorf("provided input file does not contain the last applied configuration metadata, nor managed fields of a server-side apply")
 	}
 
 	documents, err := ytbx.LoadDocuments([]byte(kubectlLastApplied.Value))
@@ -90,7 +208,19 @@ func lookUpLastAppliedConfiguration(inputFile ytbx.InputFile) (ytbx.InputFile, e
 
 	return ytbx.InputFile{
 		Documents: documents,
-		Location:  "/metadata/annotations/kubectl.kubernetes.io/last-applied-configuration",
+		Location:  lastAppliedAnnotationLocation,
+	}, nil
+}
+
+func lookUpServerSideAppliedConfiguration(inputFile ytbx.InputFile, fieldManager string) (ytbx.InputFile, error) {
+	document, err := dyff.AppliedConfiguration(inputFile.Documents[0], fieldManager)
+	if err != nil {
+		return ytbx.InputFile{}, wrap.Errorf(err, "failed to reconstruct the applied configuration from the managed fields")
+	}
+
+	return ytbx.InputFile{
+		Documents: []*yamlv3.Node{document},
+		Location:  "/metadata/managedFields",
 	}, nil
 }
 
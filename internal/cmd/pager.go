@@ -0,0 +1,108 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/gonvenience/term"
+	"github.com/gonvenience/wrap"
+)
+
+const defaultPager = "less -R"
+
+// isTerminal checks whether the output is a terminal, which is the condition
+// for using a pager at all
+var isTerminal = term.IsTerminal
+
+// pager is a writer that buffers all output and once it is closed, either
+// writes it directly to the output, or pipes it through a pager program in
+// case the output is a terminal and the content does not fit on the screen
+type pager struct {
+	buf      bytes.Buffer
+	out      io.Writer
+	disabled bool
+}
+
+func newPager(out io.Writer, disabled bool) *pager {
+	return &pager{out: out, disabled: disabled}
+}
+
+func (p *pager) Write(data []byte) (int, error) {
+	return p.buf.Write(data)
+}
+
+// Close flushes the buffered content to the output, or to the pager program
+func (p *pager) Close() error {
+	command := pagerCommand()
+	if p.disabled || command == "" || command == "cat" || !isTerminal() || !p.exceedsScreen() {
+		_, err := p.buf.WriteTo(p.out)
+		return err
+	}
+
+	cmd := exec.Command("sh", "-c", command)
+	cmd.Stdin = &p.buf
+	cmd.Stdout = p.out
+	cmd.Stderr = os.Stderr
+	cmd.Env = os.Environ()
+
+	// Similar to Git, configure less to pass through colors and to quit if the
+	// content fits on one screen after all, unless the user has own settings
+	if _, ok := os.LookupEnv("LESS"); !ok {
+		cmd.Env = append(cmd.Env, "LESS=FRX")
+	}
+
+	if _, ok := os.LookupEnv("LV"); !ok {
+		cmd.Env = append(cmd.Env, "LV=-c")
+	}
+
+	if err := cmd.Start(); err != nil {
+		// Fall back to write the content directly if there is no usable pager
+		_, err := p.buf.WriteTo(p.out)
+		return err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return wrap.Errorf(err, "failed to run pager %s", command)
+	}
+
+	return nil
+}
+
+func (p *pager) exceedsScreen() bool {
+	return bytes.Count(p.buf.Bytes(), []byte("\n")) >= term.GetTerminalHeight()
+}
+
+// pagerCommand returns the pager program to be used, where DYFF_PAGER takes
+// precedence over PAGER, and an empty setting disables paging altogether
+func pagerCommand() string {
+	for _, name := range []string{"DYFF_PAGER", "PAGER"} {
+		if value, ok := os.LookupEnv(name); ok {
+			return strings.TrimSpace(value)
+		}
+	}
+
+	return defaultPager
+}
@@ -0,0 +1,131 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/wrap"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// reportDiffCmd represents the report-diff command
+var reportDiffCmd = &cobra.Command{
+	Use:   "report-diff [flags] <old-report> <new-report>",
+	Short: "Compare two reports to see which differences are new, resolved, or changed",
+	Long: `
+Compares two reports that were written using the JSON output style, for example
+the approved drift of yesterday and the drift of today. The result lists which
+differences are new, which are resolved, and which have a changed value.
+
+With --set-exit-code, the exit code is only 1 in case there are new or changed
+differences, so that a stored report can serve as a baseline of known drift:
+
+  dyff between --output json live.yml desired.yml > today.json
+  dyff report-diff --set-exit-code approved.json today.json
+`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		previous, err := dyff.LoadReport(args[0])
+		if err != nil {
+			return wrap.Errorf(err, "failed to load report")
+		}
+
+		current, err := dyff.LoadReport(args[1])
+		if err != nil {
+			return wrap.Errorf(err, "failed to load report")
+		}
+
+		delta, err := filterDelta(dyff.DiffReports(previous, current))
+		if err != nil {
+			return err
+		}
+
+		out := newPager(os.Stdout, reportOptions.noPager)
+
+		if !reportOptions.omitHeader {
+			fmt.Fprint(out, bunt.Sprintf("differences of report _*%s*_ compared to report _*%s*_: ", args[1], args[0]))
+		}
+
+		summary := dyff.DeltaSummary{
+			ReportDelta:     delta,
+			UseGoPatchPaths: reportOptions.useGoPatchPaths,
+		}
+
+		if err := summary.WriteReport(out); err != nil {
+			return wrap.Errorf(err, "failed to print report")
+		}
+
+		for _, section := range []struct {
+			title string
+			diffs []dyff.Diff
+		}{
+			{"\nnew differences:\n", delta.New},
+			{"\nchanged differences:\n", delta.Changed},
+		} {
+			if len(section.diffs) == 0 {
+				continue
+			}
+
+			report := dyff.Report{From: current.From, To: current.To, Diffs: section.diffs}
+			if err := writeEmbeddedReport(cmd, out, bunt.Sprintf("*%s*", section.title), report); err != nil {
+				return wrap.Errorf(err, "failed to print report")
+			}
+		}
+
+		if err := out.Close(); err != nil {
+			return wrap.Errorf(err, "failed to print report")
+		}
+
+		return exitCode(len(delta.New)+len(delta.Changed) > 0)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(reportDiffCmd)
+
+	reportDiffCmd.Flags().SortFlags = false
+	reportDiffCmd.PersistentFlags().SortFlags = false
+
+	// Reports are not compared again, therefore only the filter and output
+	// flags apply
+	reportDiffCmd.Flags().StringSliceVar(&reportOptions.filters, "filter", nil, "filter the differences of both reports to the supplied paths")
+	applyOutputOptionsFlags(reportDiffCmd, false)
+}
+
+// filterDelta applies the configured path filters to the differences of the
+// delta, so that the summary and the exit code only consider those
+func filterDelta(delta dyff.ReportDelta) (dyff.ReportDelta, error) {
+	for _, diffs := range []*[]dyff.Diff{&delta.New, &delta.Resolved, &delta.Changed} {
+		report, err := applyFilters(dyff.Report{Diffs: *diffs})
+		if err != nil {
+			return dyff.ReportDelta{}, err
+		}
+
+		*diffs = report.Diffs
+	}
+
+	return delta, nil
+}
@@ -0,0 +1,103 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/gonvenience/wrap"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// resourcesCmd represents the resources command
+var resourcesCmd = &cobra.Command{
+	Use:   "resources [flags] <from>... -- <to>...",
+	Short: "Compare Kubernetes resources by identity across files and directories",
+	Long: `
+Compares Kubernetes resources that are defined in any number of files or
+directories on each side. All documents are flattened into a set of resources,
+which are identified by apiVersion, kind, namespace, and name. Resources are
+compared regardless of which file or document they are defined in, and the
+report shows the originating file of each side.
+
+Use a double dash to separate the from locations from the to locations, for
+example: dyff resources old/ extra.yml -- new/
+
+Directories are searched recursively for YAML and JSON files. Documents that
+are not Kubernetes resources are skipped, while the items of a Kubernetes List
+are treated as individual resources.
+`,
+	Aliases: []string{"res"},
+	Args: func(cmd *cobra.Command, args []string) error {
+		_, _, err := resourceLocations(cmd, args)
+		return err
+	},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		from, to, _ := resourceLocations(cmd, args)
+
+		filter, err := directoryFilter()
+		if err != nil {
+			return err
+		}
+
+		options, err := compareOptions()
+		if err != nil {
+			return err
+		}
+
+		report, err := dyff.CompareResourceSets(from, to, filter, options...)
+		if err != nil {
+			return wrap.Errorf(err, "failed to compare resources")
+		}
+
+		return writeResourceSetReport(cmd, report)
+	},
+}
+
+// resourceLocations splits the arguments into the from and to locations,
+// which are either separated by a double dash, or are exactly two arguments
+func resourceLocations(cmd *cobra.Command, args []string) ([]string, []string, error) {
+	dash := cmd.ArgsLenAtDash()
+	if dash < 0 {
+		if len(args) != 2 {
+			return nil, nil, fmt.Errorf("requires either exactly two arguments, or a double dash to separate the from and to locations")
+		}
+
+		return args[:1], args[1:], nil
+	}
+
+	if dash == 0 || dash == len(args) {
+		return nil, nil, fmt.Errorf("requires at least one from and one to location")
+	}
+
+	return args[:dash], args[dash:], nil
+}
+
+func init() {
+	rootCmd.AddCommand(resourcesCmd)
+
+	resourcesCmd.Flags().SortFlags = false
+	resourcesCmd.PersistentFlags().SortFlags = false
+
+	applyReportOptionsFlags(resourcesCmd)
+}
@@ -21,14 +21,19 @@
 package cmd
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/term"
+	"github.com/gonvenience/wrap"
 	"github.com/gonvenience/ytbx"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+
+	"github.com/homeport/dyff/pkg/dyff"
 )
 
 // ExitCode is just a way to transport the exit code to the main package
@@ -54,6 +59,12 @@ var name = func() string {
 	return filepath.Base(ep)
 }()
 
+// themeEnvVar is the environment variable used to select a theme in case the
+// respective flag was not used
+const themeEnvVar = "DYFF_THEME"
+
+var theme string
+
 // rootCmd represents the base command when called without any subcommands
 var rootCmd = &cobra.Command{
 	Use:           name,
@@ -64,6 +75,31 @@ var rootCmd = &cobra.Command{
 can transform YAML to JSON, and vice versa. The order of keys in hashes
 is preserved during the conversion.
 `,
+	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+		return applyTheme()
+	},
+}
+
+// applyTheme sets the output theme based on the theme flag, or the respective
+// environment variable in case the flag is not set
+func applyTheme() error {
+	nameOrLocation := theme
+	if nameOrLocation == "" {
+		nameOrLocation = os.Getenv(themeEnvVar)
+	}
+
+	if nameOrLocation == "" {
+		dyff.SetTheme(dyff.DarkTheme)
+		return nil
+	}
+
+	selected, err := dyff.LoadTheme(nameOrLocation)
+	if err != nil {
+		return wrap.Errorf(err, "failed to set theme")
+	}
+
+	dyff.SetTheme(selected)
+	return nil
 }
 
 // ResetSettings resets command settings to default. This is only required by
@@ -73,6 +109,31 @@ func ResetSettings() {
 	betweenCmdSettings = betweenCmdOptions{}
 	yamlCmdSettings = yamlCmdOptions{}
 	jsonCmdSettings = jsonCmdOptions{}
+	lastAppliedCmdSettings = lastAppliedCmdOptions{}
+	theme = ""
+	_ = dyff.ASCIISetting.Set("auto")
+
+	// The position of the double dash argument is not reset when parsing the
+	// flags again, which is relevant for commands that rely on it
+	for _, cmd := range rootCmd.Commands() {
+		cmd.Flags().Init(cmd.Name(), pflag.ContinueOnError)
+
+		// The help flag is not bound to a settings variable, which is why it
+		// needs to be reset explicitly
+		if help := cmd.Flags().Lookup("help"); help != nil {
+			_ = help.Value.Set("false")
+			help.Changed = false
+		}
+	}
+}
+
+// RootCommand returns the root command with all sub-commands, so that other
+// command-line tools can embed it. Report styles that are registered before
+// the command runs are available as output styles. Since the settings of the
+// commands are shared, only one command can run at a time.
+func RootCommand() *cobra.Command {
+	refreshOutputStyleUsage(rootCmd)
+	return rootCmd
 }
 
 // Execute adds all child commands to the root command and sets flags appropriately.
@@ -80,7 +141,8 @@ func ResetSettings() {
 func Execute() error {
 	// In case `KUBECTL_EXTERNAL_DIFF` is set with `dyff`, it is very likely
 	// that `kubectl` intends to use `dyff` for its `diff` command. Therefore,
-	// enable Kubernetes specific entity detection and fix the order issue.
+	// enable Kubernetes specific entity detection, fix the order issue, and
+	// compare the two directories created by `kubectl` in a dedicated mode.
 	if strings.Contains(os.Getenv("KUBECTL_EXTERNAL_DIFF"), name) {
 		// Rearrange the arguments to match `dyff between --flags from to` to
 		// mitigate an issue in `kubectl`, which puts the `from` and `to` at
@@ -99,8 +161,17 @@ func Execute() error {
 
 		// Enable Kubernetes specific entity detection implicitly
 		reportOptions.kubernetesEntityDetection = true
+
+		// Compare the directories created by `kubectl` file by file and use
+		// the exit code to signal differences like `kubectl` expects it
+		betweenCmdSettings.kubectlDiff = true
+		reportOptions.exitWithCode = true
 	}
 
+	// Report styles can be registered after the flags were defined, therefore
+	// the list of supported styles in the usage is updated right before use
+	refreshOutputStyleUsage(rootCmd)
+
 	if err := rootCmd.Execute(); err != nil {
 		// Special case ExitCode, which means that we will exit immediately
 		// with the given exit code
@@ -126,5 +197,22 @@ func init() {
 	rootCmd.PersistentFlags().VarP(&bunt.ColorSetting, "color", "c", "specify color usage: on, off, or auto")
 	rootCmd.PersistentFlags().VarP(&bunt.TrueColorSetting, "truecolor", "t", "specify true color usage: on, off, or auto")
 	rootCmd.PersistentFlags().IntVarP(&term.FixedTerminalWidth, "fixed-width", "w", -1, "disable terminal width detection and use provided fixed value")
+	rootCmd.PersistentFlags().Var(&dyff.ASCIISetting, "ascii", "specify ASCII-only output usage for change indicators and helper lines: on, off, or auto (based on locale)")
+	rootCmd.PersistentFlags().Lookup("ascii").NoOptDefVal = "on"
+	rootCmd.PersistentFlags().StringVar(&theme, "theme", "", fmt.Sprintf("specify the color theme by name (%s) or theme file location, defaults to %s environment variable", strings.Join(dyff.ThemeNames(), ", "), themeEnvVar))
 	rootCmd.PersistentFlags().BoolVarP(&ytbx.PreserveKeyOrderInJSON, "preserve-key-order-in-json", "k", false, "use ordered keys during JSON decoding (non standard behavior)")
 }
+
+func refreshOutputStyleUsage(cmd *cobra.Command) {
+	if flag := cmd.Flags().Lookup("output"); flag != nil {
+		flag.Usage = outputStyleUsage()
+	}
+
+	if flag := cmd.Flags().Lookup("style-option"); flag != nil {
+		flag.Usage = styleOptionUsage()
+	}
+
+	for _, subCmd := range cmd.Commands() {
+		refreshOutputStyleUsage(subCmd)
+	}
+}
@@ -0,0 +1,418 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/wrap"
+	"github.com/gonvenience/ytbx"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// sectionedReport combines the reports of multiple comparisons, for example
+// of all files of two directories, with one section per compared entity
+type sectionedReport struct {
+	entity   string
+	from     string
+	to       string
+	sections []reportSection
+}
+
+// reportSection is the comparison result of one entity, where the kind is
+// ADDITION or REMOVAL for entities that only exist on one side, and
+// MODIFICATION for entities that exist on both sides, unless they could not be
+// compared, which is described by the error
+type reportSection struct {
+	name         string
+	kind         rune
+	fromLocation string
+	toLocation   string
+	report       dyff.Report
+	err          error
+}
+
+type jsonSectionedReport struct {
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	Sections []jsonSection `json:"sections"`
+}
+
+type jsonSection struct {
+	Name   string          `json:"name"`
+	Change string          `json:"change"`
+	From   string          `json:"from,omitempty"`
+	To     string          `json:"to,omitempty"`
+	Report json.RawMessage `json:"report,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// writeDirectoryReport writes one combined report for all files of the two
+// directories, with a section for each file that has differences
+func writeDirectoryReport(cmd *cobra.Command, report dyff.DirectoryReport) error {
+	_, err := writeProcessedDirectoryReport(cmd, report)
+	return err
+}
+
+// writeProcessedDirectoryReport writes the report like writeDirectoryReport,
+// and returns the differences of all shown files as one combined report
+func writeProcessedDirectoryReport(cmd *cobra.Command, report dyff.DirectoryReport) (dyff.Report, error) {
+	result := sectionedReport{
+		entity: "file",
+		from:   humanReadableFilename(report.From),
+		to:     humanReadableFilename(report.To),
+	}
+
+	for _, file := range report.Files {
+		result.sections = append(result.sections, reportSection{
+			name:   file.Path,
+			kind:   file.Kind,
+			report: file.Report,
+			err:    file.Err,
+		})
+	}
+
+	return writeSectionedReport(cmd, result)
+}
+
+// writeResourceSetReport writes the comparison result of two sets of
+// Kubernetes resources, with a section per added, removed, or changed resource
+func writeResourceSetReport(cmd *cobra.Command, report dyff.ResourceSetReport) error {
+	result := sectionedReport{
+		entity: "resource",
+		from:   humanReadableLocations(report.From),
+		to:     humanReadableLocations(report.To),
+	}
+
+	for _, resource := range report.Resources {
+		result.sections = append(result.sections, reportSection{
+			name:         resource.ID.String(),
+			kind:         resource.Kind,
+			fromLocation: resource.FromLocation,
+			toLocation:   resource.ToLocation,
+			report:       resource.Report,
+		})
+	}
+
+	_, err := writeSectionedReport(cmd, result)
+	return err
+}
+
+// writeSectionedReport writes the sections of the report that have
+// differences after the filters, the baseline, and the policy are applied.
+// Added or removed entities are not shown if path filters are set, since they
+// have no differences at these paths. The exit code considers all shown
+// sections. Sections that could not be compared are shown with their error,
+// and fail the command after the report is written. The differences of all
+// shown sections are returned as one combined report, where the documents are
+// named after the sections, and added or removed entities are differences of
+// the whole document.
+func writeSectionedReport(cmd *cobra.Command, report sectionedReport) (dyff.Report, error) {
+	sections, combined, err := prepareSections(report.sections)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	out := newPager(os.Stdout, reportOptions.noPager)
+
+	if style, ok := dyff.LookupReportStyle(reportOptions.style); ok && style.Name == "json" {
+		err = writeSectionsAsJSON(cmd, out, report, sections)
+	} else {
+		err = writeSections(cmd, out, report, sections)
+	}
+
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	if err := out.Close(); err != nil {
+		return dyff.Report{}, wrap.Errorf(err, "failed to print report")
+	}
+
+	if failed := failedSections(sections); failed > 0 {
+		return combined, fmt.Errorf("failed to compare %s", text.Plural(failed, report.entity))
+	}
+
+	return combined, reportExitCode(combined)
+}
+
+// prepareSections applies the filters, the baseline, and the policy to the
+// reports of the sections, and returns the sections that have differences, as
+// well as one report with all of their differences to derive the exit code
+func prepareSections(sections []reportSection) ([]reportSection, dyff.Report, error) {
+	var (
+		result   []reportSection
+		combined dyff.Report
+		compared int
+		stale    = map[string]int{}
+	)
+
+	for _, section := range sections {
+		switch {
+		case section.err != nil:
+			// sections that could not be compared are always shown
+
+		case section.kind == dyff.ADDITION || section.kind == dyff.REMOVAL:
+			if reportOptions.filters != nil {
+				continue
+			}
+
+			combined.Diffs = append(combined.Diffs, dyff.Diff{
+				Path:    ytbx.Path{Root: &ytbx.InputFile{Location: section.name, Names: []string{section.name}}},
+				Details: []dyff.Detail{{Kind: section.kind}},
+			})
+
+		default:
+			report, err := applyFilters(section.report)
+			if err != nil {
+				return nil, dyff.Report{}, err
+			}
+
+			var staleEntries []dyff.BaselineEntry
+			if report, staleEntries, err = applyBaselineEntries(report); err != nil {
+				return nil, dyff.Report{}, err
+			}
+
+			compared++
+			for _, entry := range staleEntries {
+				stale[entry.String()]++
+			}
+
+			if report, err = applyPolicy(report); err != nil {
+				return nil, dyff.Report{}, err
+			}
+
+			if len(report.Diffs) == 0 {
+				continue
+			}
+
+			section.report = report
+			combined.Diffs = append(combined.Diffs, namedDiffs(section.name, report)...)
+		}
+
+		result = append(result, section)
+	}
+
+	// a baseline entry is only stale if it does not match in any section
+	for entry, count := range stale {
+		if count == compared {
+			warnStaleBaselineEntry(entry)
+		}
+	}
+
+	return result, combined, nil
+}
+
+// writeSections writes a summary line and a section per entity using the
+// configured output style for the reports of the changed entities
+func writeSections(cmd *cobra.Command, out io.Writer, report sectionedReport, sections []reportSection) error {
+	if !reportOptions.omitHeader {
+		var added, removed, changed int
+		for _, section := range sections {
+			switch {
+			case section.err != nil:
+				continue
+
+			case section.kind == dyff.ADDITION:
+				added++
+
+			case section.kind == dyff.REMOVAL:
+				removed++
+
+			default:
+				changed++
+			}
+		}
+
+		fmt.Fprintf(out, "%s changed, %s added, and %s removed between %s and %s\n",
+			text.Plural(changed, report.entity),
+			text.Plural(added, report.entity),
+			text.Plural(removed, report.entity),
+			report.from,
+			report.to,
+		)
+
+		if failed := failedSections(sections); failed > 0 {
+			fmt.Fprintf(out, "%s could not be compared\n", text.Plural(failed, report.entity))
+		}
+	}
+
+	for _, section := range sections {
+		if section.err != nil {
+			fmt.Fprint(out, bunt.Sprintf("\n! %s %s: _*%s*_\n  %s\n",
+				report.entity,
+				sectionChange(section.kind, section.err),
+				section.name,
+				section.err.Error(),
+			))
+			continue
+		}
+
+		title := bunt.Sprintf("\n%s %s %s: _*%s*_\n",
+			dyff.Symbol(string(section.kind)),
+			report.entity,
+			sectionChange(section.kind, section.err),
+			section.name,
+		)
+
+		switch {
+		case section.kind == dyff.ADDITION && section.toLocation != "":
+			title += fmt.Sprintf("  in %s\n", section.toLocation)
+
+		case section.kind == dyff.REMOVAL && section.fromLocation != "":
+			title += fmt.Sprintf("  in %s\n", section.fromLocation)
+
+		case section.kind == dyff.MODIFICATION && section.fromLocation != section.toLocation:
+			title += fmt.Sprintf("  from %s\n  to %s\n", section.fromLocation, section.toLocation)
+
+		case section.kind == dyff.MODIFICATION && section.fromLocation != "":
+			title += fmt.Sprintf("  in %s\n", section.fromLocation)
+		}
+
+		if section.kind != dyff.MODIFICATION {
+			fmt.Fprint(out, title)
+			continue
+		}
+
+		if err := writeEmbeddedReport(cmd, out, title, section.report); err != nil {
+			return wrap.Errorf(err, "failed to print report of %s", section.name)
+		}
+	}
+
+	return nil
+}
+
+// writeSectionsAsJSON writes all sections as one JSON document, where the
+// reports of changed entities use the JSON report format
+func writeSectionsAsJSON(cmd *cobra.Command, out io.Writer, report sectionedReport, sections []reportSection) error {
+	result := jsonSectionedReport{
+		From:     bunt.RemoveAllEscapeSequences(report.from),
+		To:       bunt.RemoveAllEscapeSequences(report.to),
+		Sections: make([]jsonSection, 0, len(sections)),
+	}
+
+	for _, section := range sections {
+		entry := jsonSection{
+			Name:   section.name,
+			Change: sectionChange(section.kind, section.err),
+			From:   section.fromLocation,
+			To:     section.toLocation,
+		}
+
+		switch {
+		case section.err != nil:
+			entry.Error = section.err.Error()
+
+		case section.kind == dyff.MODIFICATION:
+			reportWriter, err := newReportWriter(cmd, section.report)
+			if err != nil {
+				return err
+			}
+
+			var buf bytes.Buffer
+			if err := reportWriter.WriteReport(&buf); err != nil {
+				return wrap.Errorf(err, "failed to print report of %s", section.name)
+			}
+
+			entry.Report = json.RawMessage(bytes.TrimSpace(buf.Bytes()))
+		}
+
+		result.Sections = append(result.Sections, entry)
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return wrap.Errorf(err, "failed to print report")
+	}
+
+	_, err = fmt.Fprintf(out, "%s\n", data)
+	return err
+}
+
+// sectionChange returns how the entity of the section changed
+func sectionChange(kind rune, err error) string {
+	if err != nil {
+		return "failed"
+	}
+
+	switch kind {
+	case dyff.ADDITION:
+		return "added"
+
+	case dyff.REMOVAL:
+		return "removed"
+	}
+
+	return "changed"
+}
+
+// namedDiffs returns the differences of the report, where the documents are
+// named after the section, so that the differences of all sections can be
+// told apart in one combined report
+func namedDiffs(name string, report dyff.Report) []dyff.Diff {
+	root := &ytbx.InputFile{Location: name, Documents: report.From.Documents}
+	for idx := range root.Documents {
+		if len(root.Documents) > 1 {
+			root.Names = append(root.Names, fmt.Sprintf("%s, document #%d", name, idx+1))
+		} else {
+			root.Names = append(root.Names, name)
+		}
+	}
+
+	result := make([]dyff.Diff, len(report.Diffs))
+	for i, diff := range report.Diffs {
+		diff.Path.Root = root
+		result[i] = diff
+	}
+
+	return result
+}
+
+// failedSections returns the number of sections that could not be compared
+func failedSections(sections []reportSection) int {
+	var result int
+	for _, section := range sections {
+		if section.err != nil {
+			result++
+		}
+	}
+
+	return result
+}
+
+// humanReadableLocations joins the human readable names of the locations
+func humanReadableLocations(locations []string) string {
+	result := make([]string, len(locations))
+	for i, location := range locations {
+		result[i] = humanReadableFilename(location)
+	}
+
+	return strings.Join(result, ", ")
+}
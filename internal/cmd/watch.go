@@ -0,0 +1,225 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/term"
+	"github.com/gonvenience/wrap"
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+// watchDebounce is the time to wait for further changes of the inputs before
+// the comparison is started, since editors usually write files in many steps
+const watchDebounce = 250 * time.Millisecond
+
+const clearScreen = "\x1b[H\x1b[2J"
+
+// watchRunHook is called after every run of the watch mode, which stops once
+// the hook returns true. It is only used by the tests.
+var watchRunHook func() bool
+
+// watchBetween compares the inputs every time one of them changes and shows
+// which differences are new, resolved, or changed since the previous run
+func watchBetween(cmd *cobra.Command, from string, to string) error {
+	if betweenCmdSettings.kubectlDiff {
+		return fmt.Errorf("watching the inputs is not supported when used by kubectl diff")
+	}
+
+	for _, location := range []string{from, to} {
+		if _, err := os.Stat(location); err != nil {
+			return fmt.Errorf("watching the inputs is only supported for local files and directories, %s cannot be watched", location)
+		}
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return wrap.Errorf(err, "failed to watch input files")
+	}
+	defer watcher.Close()
+
+	for _, location := range []string{from, to} {
+		if err := addWatch(watcher, location); err != nil {
+			return wrap.Errorf(err, "failed to watch %s", location)
+		}
+	}
+
+	// The report is shown over and over again, so paging is not an option
+	reportOptions.noPager = true
+
+	var previous *dyff.Report
+	run := func() {
+		if term.IsTerminal() {
+			fmt.Print(clearScreen)
+		}
+
+		bunt.Printf("watching _*%s*_ and _*%s*_ for changes, last run at %s\n\n", from, to, time.Now().Format("15:04:05"))
+
+		current, err := watchRun(cmd, from, to)
+		if err != nil {
+			bunt.Print("Coral{*Error:*} ")
+			fmt.Println(err)
+			return
+		}
+
+		if previous != nil {
+			summary := dyff.DeltaSummary{
+				ReportDelta:     dyff.DiffReports(*previous, current),
+				UseGoPatchPaths: reportOptions.useGoPatchPaths,
+			}
+
+			bunt.Printf("\n*since previous run:* ")
+			_ = summary.WriteReport(os.Stdout)
+		}
+
+		previous = &current
+	}
+
+	run()
+	if watchRunHook != nil && watchRunHook() {
+		return nil
+	}
+
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+
+			if !isWatched(event.Name, from, to) {
+				continue
+			}
+
+			if event.Op&fsnotify.Create != 0 && isDirectory(event.Name) {
+				_ = addWatch(watcher, event.Name)
+			}
+
+			timer.Reset(watchDebounce)
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+
+			return wrap.Errorf(err, "failed to watch input files")
+
+		case <-timer.C:
+			run()
+			if watchRunHook != nil && watchRunHook() {
+				return nil
+			}
+		}
+	}
+}
+
+// watchRun compares and writes the report once, and returns the differences
+// as they were written, after the filters, the baseline, and the policy are
+// applied, to be able to compare them with the next run. In directory mode,
+// the differences of all files are combined in one report, which includes the
+// added and removed files.
+func watchRun(cmd *cobra.Command, from string, to string) (dyff.Report, error) {
+	if isDirectory(from) && isDirectory(to) {
+		report, err := compareDirectories(from, to)
+		if err != nil {
+			return dyff.Report{}, err
+		}
+
+		result, err := writeProcessedDirectoryReport(cmd, report)
+		if err != nil && !isExitCode(err) {
+			return dyff.Report{}, err
+		}
+
+		return result, nil
+	}
+
+	report, err := compareFiles(from, to)
+	if err != nil {
+		return dyff.Report{}, err
+	}
+
+	result, err := writeProcessedReport(cmd, report)
+	if err != nil && !isExitCode(err) {
+		return dyff.Report{}, err
+	}
+
+	return result, nil
+}
+
+// addWatch adds the location to the watcher, which is the parent directory for
+// files, so that files that are replaced by editors are still watched, and all
+// directories of the directory tree otherwise
+func addWatch(watcher *fsnotify.Watcher, location string) error {
+	if !isDirectory(location) {
+		return watcher.Add(filepath.Dir(location))
+	}
+
+	return filepath.Walk(location, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if info.IsDir() {
+			return watcher.Add(path)
+		}
+
+		return nil
+	})
+}
+
+// isWatched returns whether the changed file is one of the watched inputs, or
+// part of one of the watched directories
+func isWatched(name string, locations ...string) bool {
+	name = filepath.Clean(name)
+	for _, location := range locations {
+		location = filepath.Clean(location)
+		if name == location {
+			return true
+		}
+
+		if !isDirectory(location) {
+			continue
+		}
+
+		if rel, err := filepath.Rel(location, name); err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func isExitCode(err error) bool {
+	_, ok := err.(ExitCode)
+	return ok
+}
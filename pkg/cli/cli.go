@@ -0,0 +1,42 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package cli provides the dyff command-line interface to other command-line
+// tools, for example to add dyff as a sub-command with additional output
+// styles that are registered using dyff.RegisterReportStyle.
+package cli
+
+import (
+	"github.com/spf13/cobra"
+
+	"github.com/homeport/dyff/internal/cmd"
+)
+
+// ExitCode is the error returned by the commands in case the exit code is
+// not zero, for example if differences were found and `--set-exit-code` is
+// used, the cause is set in case of an actual error
+type ExitCode = cmd.ExitCode
+
+// Command returns the dyff root command with all sub-commands. Its usage
+// lists all report styles that were registered before, which can be used
+// with `--output`.
+func Command() *cobra.Command {
+	return cmd.RootCommand()
+}
@@ -0,0 +1,92 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	. "github.com/homeport/dyff/pkg/cli"
+	"github.com/homeport/dyff/pkg/dyff"
+)
+
+type pathsOnlyReport struct {
+	dyff.Report
+}
+
+func (report *pathsOnlyReport) WriteReport(out io.Writer) error {
+	for _, diff := range report.Diffs {
+		fmt.Fprintln(out, diff.Path.ToGoPatchStyle())
+	}
+
+	return nil
+}
+
+var _ = Describe("embedding the command", func() {
+	It("should offer registered report styles as output styles", func() {
+		Expect(dyff.RegisterReportStyle(dyff.ReportStyle{
+			Name: "paths-only",
+			New: func(report dyff.Report, _ dyff.ReportWriterOptions) (dyff.ReportWriter, error) {
+				return &pathsOnlyReport{Report: report}, nil
+			},
+		})).To(Succeed())
+
+		var files []string
+		for _, content := range []string{"a: 1\nb: 2\n", "a: 2\nb: 3\n"} {
+			file, err := ioutil.TempFile("", "cli-test")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(file.Name())
+
+			_, err = file.WriteString(content)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+			files = append(files, file.Name())
+		}
+
+		command := Command()
+		Expect(command.Commands()).ToNot(BeEmpty())
+
+		between, _, err := command.Find([]string{"between"})
+		Expect(err).ToNot(HaveOccurred())
+		Expect(between.Flags().Lookup("output").Usage).To(ContainSubstring("paths-only"))
+
+		r, w, err := os.Pipe()
+		Expect(err).ToNot(HaveOccurred())
+
+		tmp := os.Stdout
+		os.Stdout = w
+		command.SetArgs([]string{"between", "--output", "paths-only", files[0], files[1]})
+		err = command.Execute()
+		os.Stdout = tmp
+		w.Close()
+		Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		_, _ = io.Copy(&buf, r)
+		Expect(buf.String()).To(Equal("/a\n/b\n"))
+	})
+})
@@ -0,0 +1,108 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+)
+
+// ASCIISetting defines whether the output is restricted to ASCII characters,
+// which can be on, off, or auto (based on the locale settings)
+var ASCIISetting bunt.SwitchState
+
+// UseASCII returns whether only ASCII characters should be used for the change
+// indicators and helper lines, either because it was explicitly configured or
+// because the locale settings suggest that the output does not support UTF-8
+func UseASCII() bool {
+	switch ASCIISetting.String() {
+	case "on":
+		return true
+
+	case "off":
+		return false
+	}
+
+	return !localeSupportsUTF8()
+}
+
+// localeSupportsUTF8 checks the locale environment variables in order of their
+// precedence. Without any locale setting, UTF-8 support is assumed.
+func localeSupportsUTF8() bool {
+	for _, name := range []string{"LC_ALL", "LC_CTYPE", "LANG"} {
+		if value := os.Getenv(name); value != "" {
+			value = strings.ToLower(value)
+			return strings.Contains(value, "utf-8") || strings.Contains(value, "utf8")
+		}
+	}
+
+	return true
+}
+
+// asciiSymbols maps the non-ASCII symbols that dyff uses for its own
+// decorations to their ASCII equivalents
+var asciiSymbols = map[string]string{
+	string(MODIFICATION): "~",
+	string(ORDERCHANGE):  "<>",
+	"↵":                  "$",
+	"·":                  ".",
+	"…":                  "...",
+}
+
+// indentHelperLines matches the indentation at the start of a line that neat
+// renders, with or without the (colored) indent helper lines
+var indentHelperLines = regexp.MustCompile(`^(?:(?:\x1b\[[0-9;]*m)?(?:│ |  )(?:\x1b\[0m)?)+`)
+
+// Symbol returns the provided decoration symbol, or its ASCII equivalent if
+// ASCII-only output is enabled
+func Symbol(symbol string) string {
+	if replacement, ok := asciiSymbols[symbol]; ok && UseASCII() {
+		return replacement
+	}
+
+	return symbol
+}
+
+// indicator returns the symbol to be used for the provided kind of change
+func indicator(kind rune) string {
+	return Symbol(string(kind))
+}
+
+// ASCIISafe replaces the indent helper lines of the neat output with ASCII
+// equivalents if ASCII-only output is enabled. Only the indentation at the
+// start of each line is changed, the content itself is returned unchanged.
+func ASCIISafe(text string) string {
+	if !UseASCII() || !strings.Contains(text, "│") {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if indent := indentHelperLines.FindString(line); indent != "" {
+			lines[i] = strings.Replace(indent, "│", "|", -1) + line[len(indent):]
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,274 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// BaselineEntry describes an accepted difference by its path, and optionally
+// the expected from and to values, either as the exact value or as a regular
+// expression. An entry without values accepts any difference of the path. The
+// path can be in Go-patch or Dot-Style. For inputs with more than one document,
+// the entry has to name the document, either by its number (starting with 1)
+// or by its name, for example the Kubernetes resource identity.
+type BaselineEntry struct {
+	Path      string  `yaml:"path"`
+	Document  string  `yaml:"document,omitempty"`
+	From      *string `yaml:"from,omitempty"`
+	To        *string `yaml:"to,omitempty"`
+	FromRegex string  `yaml:"fromRegex,omitempty"`
+	ToRegex   string  `yaml:"toRegex,omitempty"`
+	Reason    string  `yaml:"reason,omitempty"`
+
+	path      ytbx.Path
+	fromRegex *regexp.Regexp
+	toRegex   *regexp.Regexp
+}
+
+// Baseline is a list of accepted differences
+type Baseline struct {
+	Accepted []BaselineEntry `yaml:"accepted"`
+}
+
+// LoadBaseline loads a baseline file, which lists the accepted differences
+func LoadBaseline(location string) (Baseline, error) {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return Baseline{}, err
+	}
+
+	var baseline Baseline
+	if err := yamlv3.Unmarshal(data, &baseline); err != nil {
+		return Baseline{}, fmt.Errorf("failed to parse baseline file %s: %w", location, err)
+	}
+
+	for i := range baseline.Accepted {
+		if err := baseline.Accepted[i].compile(); err != nil {
+			return Baseline{}, fmt.Errorf("failed to parse baseline file %s: %w", location, err)
+		}
+	}
+
+	return baseline, nil
+}
+
+// NewBaselineEntry prepares the given entry to be used in a baseline, which
+// fails if the path or the regular expressions of the entry are invalid
+func NewBaselineEntry(entry BaselineEntry) (BaselineEntry, error) {
+	return entry, entry.compile()
+}
+
+func (entry *BaselineEntry) compile() error {
+	if strings.TrimSpace(entry.Path) == "" {
+		return fmt.Errorf("baseline entry without a path")
+	}
+
+	path, err := ytbx.ParsePathStringUnsafe(entry.Path)
+	if err != nil {
+		return fmt.Errorf("baseline entry with invalid path %s: %w", entry.Path, err)
+	}
+
+	entry.path = path
+
+	for _, expr := range []struct {
+		source string
+		target **regexp.Regexp
+	}{
+		{entry.FromRegex, &entry.fromRegex},
+		{entry.ToRegex, &entry.toRegex},
+	} {
+		if expr.source == "" {
+			continue
+		}
+
+		regex, err := regexp.Compile(expr.source)
+		if err != nil {
+			return fmt.Errorf("baseline entry of path %s with invalid regular expression: %w", entry.Path, err)
+		}
+
+		*expr.target = regex
+	}
+
+	return nil
+}
+
+// String returns the path of the baseline entry with the document and the
+// reason, if there are any
+func (entry BaselineEntry) String() string {
+	result := entry.Path
+	if entry.Document != "" {
+		if _, err := strconv.Atoi(entry.Document); err == nil {
+			result = fmt.Sprintf("%s in document #%s", result, entry.Document)
+		} else {
+			result = fmt.Sprintf("%s in %s", result, entry.Document)
+		}
+	}
+
+	if entry.Reason != "" {
+		return fmt.Sprintf("%s (%s)", result, entry.Reason)
+	}
+
+	return result
+}
+
+// Accepts returns whether the entry accepts the given difference, which
+// requires the document, the path, and all details to match the entry
+func (entry BaselineEntry) Accepts(diff Diff) bool {
+	return entry.accepts(diff, nil)
+}
+
+// accepts works like Accepts, but also resolves Dot-Style paths against the
+// respective document of the given to input, for example for differences of
+// named list entries that only exist in the to document
+func (entry BaselineEntry) accepts(diff Diff, to *ytbx.InputFile) bool {
+	if !entry.matchesDocument(diff.Path) || !entry.matchesPath(diff.Path, to) {
+		return false
+	}
+
+	for _, detail := range diff.Details {
+		if !matchesValue(detail.From, entry.From, entry.fromRegex) ||
+			!matchesValue(detail.To, entry.To, entry.toRegex) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (entry BaselineEntry) matchesDocument(path ytbx.Path) bool {
+	if entry.Document == "" {
+		return path.Root == nil || len(path.Root.Documents) <= 1
+	}
+
+	if number, err := strconv.Atoi(entry.Document); err == nil {
+		return number == path.DocumentIdx+1
+	}
+
+	return entry.Document == path.RootDescription()
+}
+
+// matchesPath compares the paths in Go-patch style, since a Dot-Style path
+// can only be resolved into named list entries using the document itself
+func (entry BaselineEntry) matchesPath(path ytbx.Path, to *ytbx.InputFile) bool {
+	expected := path.ToGoPatchStyle()
+	if strings.HasPrefix(entry.Path, "/") {
+		return entry.path.ToGoPatchStyle() == expected
+	}
+
+	// documents can be nil, for example for resources that only exist on one
+	// side, or in reports that were loaded from JSON
+	var candidates []*yamlv3.Node
+	for _, input := range []*ytbx.InputFile{path.Root, to} {
+		if input == nil || path.DocumentIdx >= len(input.Documents) {
+			continue
+		}
+
+		if document := input.Documents[path.DocumentIdx]; document != nil && (document.Kind != yamlv3.DocumentNode || len(document.Content) > 0) {
+			candidates = append(candidates, document)
+		}
+	}
+
+	for _, document := range candidates {
+		if document.Kind != yamlv3.DocumentNode {
+			document = &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{document}}
+		}
+
+		if parsed, err := ytbx.ParseDotStylePathString(entry.Path, document); err == nil && parsed.ToGoPatchStyle() == expected {
+			return true
+		}
+	}
+
+	// without documents, the Dot-Style of the path uses the same names
+	return len(candidates) == 0 && (entry.path.ToGoPatchStyle() == expected || entry.Path == path.ToDotStyle())
+}
+
+func matchesValue(node *yamlv3.Node, expected *string, regex *regexp.Regexp) bool {
+	if expected == nil && regex == nil {
+		return true
+	}
+
+	if node == nil {
+		return false
+	}
+
+	value := valueText(node)
+	if expected != nil && value != *expected {
+		return false
+	}
+
+	return regex == nil || regex.MatchString(value)
+}
+
+// valueText returns the plain value of scalar nodes, or the YAML text of
+// complex nodes
+func valueText(node *yamlv3.Node) string {
+	node = followAlias(node)
+	if node.Kind == yamlv3.ScalarNode {
+		return node.Value
+	}
+
+	data, err := yamlv3.Marshal(node)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(data))
+}
+
+// ApplyBaseline returns a new report without the differences that are
+// accepted by the baseline, and the list of baseline entries that did not
+// match any difference of the report (stale entries)
+func (r Report) ApplyBaseline(baseline Baseline) (Report, []BaselineEntry) {
+	result := Report{
+		From: r.From,
+		To:   r.To,
+	}
+
+	used := make([]bool, len(baseline.Accepted))
+	for _, diff := range r.Diffs {
+		accepted := false
+		for i, entry := range baseline.Accepted {
+			if entry.accepts(diff, &r.To) {
+				used[i], accepted = true, true
+			}
+		}
+
+		if !accepted {
+			result.Diffs = append(result.Diffs, diff)
+		}
+	}
+
+	var stale []BaselineEntry
+	for i, entry := range baseline.Accepted {
+		if !used[i] {
+			stale = append(stale, entry)
+		}
+	}
+
+	return result, stale
+}
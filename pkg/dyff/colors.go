@@ -27,12 +27,6 @@ import (
 	"github.com/lucasb-eyer/go-colorful"
 )
 
-var (
-	additionGreen      = color("#58BF38")
-	modificationYellow = color("#C7C43F")
-	removalRed         = color("#B9311B")
-)
-
 func color(hex string) colorful.Color {
 	color, _ := colorful.Hex(hex)
 	return color
@@ -47,23 +41,23 @@ func render(format string, a ...interface{}) string {
 }
 
 func green(format string, a ...interface{}) string {
-	return colored(additionGreen, render(format, a...))
+	return colored(currentTheme.Addition, render(format, a...))
 }
 
 func red(format string, a ...interface{}) string {
-	return colored(removalRed, render(format, a...))
+	return colored(currentTheme.Removal, render(format, a...))
 }
 
 func yellow(format string, a ...interface{}) string {
-	return colored(modificationYellow, render(format, a...))
+	return colored(currentTheme.Modification, render(format, a...))
 }
 
 func lightgreen(format string, a ...interface{}) string {
-	return colored(bunt.LightGreen, render(format, a...))
+	return colored(currentTheme.AdditionLight, render(format, a...))
 }
 
 func lightred(format string, a ...interface{}) string {
-	return colored(bunt.LightSalmon, render(format, a...))
+	return colored(currentTheme.RemovalLight, render(format, a...))
 }
 
 func bold(format string, a ...interface{}) string {
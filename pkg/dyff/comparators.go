@@ -0,0 +1,145 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Comparator compares two nodes using domain-specific rules, for example for
+// semantic versions, CIDRs, or case-insensitive host names. Either of the
+// nodes can be nil in case it does not exist. If the comparator handles the
+// nodes, it returns the details of the differences, which are empty in case
+// the nodes are considered to be equal. Otherwise, it returns false to fall
+// back to the default comparison logic. With more than one worker (see
+// Workers), a comparator is called concurrently and has to be safe for use by
+// multiple goroutines.
+type Comparator interface {
+	Compare(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) (details []Detail, handled bool, err error)
+}
+
+// ComparatorFunc is an adapter to use a function as a comparator
+type ComparatorFunc func(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Detail, bool, error)
+
+// Compare calls the comparator function
+func (f ComparatorFunc) Compare(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Detail, bool, error) {
+	return f(path, from, to)
+}
+
+// ScalarEquality creates a comparator for scalar values, that considers two
+// values to be equal if the provided function says so. Otherwise, and for
+// all other nodes, it falls back to the default comparison logic.
+func ScalarEquality(equal func(from string, to string) bool) Comparator {
+	return ComparatorFunc(func(_ ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Detail, bool, error) {
+		from, to = followAlias(from), followAlias(to)
+		if from == nil || to == nil || from.Kind != yamlv3.ScalarNode || to.Kind != yamlv3.ScalarNode {
+			return nil, false, nil
+		}
+
+		if equal(from.Value, to.Value) {
+			return nil, true, nil
+		}
+
+		return nil, false, nil
+	})
+}
+
+// registeredComparator is a comparator that is used for all nodes that either
+// match the path pattern, or have the YAML tag
+type registeredComparator struct {
+	pattern    []string
+	tag        string
+	comparator Comparator
+}
+
+// PathComparator registers a comparator for the nodes with a path that matches
+// the Go-Patch style pattern, which uses the same syntax as policy rules: A
+// star matches one path element, two stars match any number of path elements,
+// for example /spec/**/image. Comparators are used in the order they are
+// registered, before the default comparison logic, for nodes that exist in
+// both documents. Added or removed map keys and list entries are reported
+// without calling a comparator. They are not used to find the same entries in
+// lists, which compares the entries by their content.
+func PathComparator(pattern string, comparator Comparator) CompareOption {
+	return func(settings *compareSettings) {
+		settings.Comparators = append(settings.Comparators, registeredComparator{
+			pattern:    splitPolicyPath(pattern),
+			comparator: comparator,
+		})
+	}
+}
+
+// TagComparator registers a comparator for the nodes, where the from or to
+// node has the given YAML tag, for example !cidr. Like for PathComparator,
+// the nodes have to exist in both documents.
+func TagComparator(tag string, comparator Comparator) CompareOption {
+	return func(settings *compareSettings) {
+		settings.Comparators = append(settings.Comparators, registeredComparator{
+			tag:        tag,
+			comparator: comparator,
+		})
+	}
+}
+
+func (registered registeredComparator) matches(elements func() []string, from *yamlv3.Node, to *yamlv3.Node) bool {
+	if registered.tag != "" {
+		return (from != nil && from.Tag == registered.tag) || (to != nil && to.Tag == registered.tag)
+	}
+
+	return matchesPolicyPath(registered.pattern, elements())
+}
+
+// customComparison runs the registered comparators for the nodes, and returns
+// the differences of the first comparator that handles them
+func (compare *compare) customComparison(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, bool, error) {
+	var elements []string
+	pathElements := func() []string {
+		if elements == nil {
+			elements = pathElementStrings(path)
+		}
+
+		return elements
+	}
+
+	for _, registered := range compare.settings.Comparators {
+		if !registered.matches(pathElements, from, to) {
+			continue
+		}
+
+		details, handled, err := registered.comparator.Compare(path, from, to)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if !handled {
+			continue
+		}
+
+		if len(details) == 0 {
+			return []Diff{}, true, nil
+		}
+
+		return []Diff{{Path: path, Details: details}}, true, nil
+	}
+
+	return nil, false, nil
+}
@@ -21,9 +21,15 @@
 package dyff_test
 
 import (
+	"bytes"
+	"context"
+	"fmt"
+	"strings"
+
 	"github.com/gonvenience/ytbx"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+	yamlv3 "gopkg.in/yaml.v3"
 
 	. "github.com/homeport/dyff/pkg/dyff"
 )
@@ -743,6 +749,33 @@ listY: [ Yo, Yo, Yo ]
 
 				Expect(report.Filter(path("/does/not/exist"))).To(BeEquivalentTo(Report{}))
 			})
+
+			It("should provide statistics of the differences in a report", func() {
+				report := Report{Diffs: []Diff{
+					singleDiff("/yaml/map/foobar", ADDITION, nil, "foobar"),
+					doubleDiff("/yaml/list", ADDITION, nil, "foo", REMOVAL, "bar", nil),
+					singleDiff("/other", MODIFICATION, "foo", "bar"),
+				}}
+
+				stats := report.Stats()
+				Expect(stats.Differences).To(Equal(3))
+				Expect(stats.ByKind).To(Equal(map[rune]int{ADDITION: 2, REMOVAL: 1, MODIFICATION: 1}))
+				Expect(stats.ByTopLevelKey).To(Equal(map[string]int{"yaml": 2, "other": 1}))
+				Expect(stats.ByResource).To(BeEmpty())
+			})
+
+			It("should provide statistics by Kubernetes resource", func() {
+				report, err := CompareInputFiles(
+					file(assets("kubernetes-yaml", "from.yml")),
+					file(assets("kubernetes-yaml", "to.yml")),
+				)
+
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Stats().ByResource).To(Equal(map[string]int{
+					"ReplicationController/kube-system/kube-registry-v0": 3,
+					"Service/kube-system/kube-registry":                  1,
+				}))
+			})
 		})
 
 		Context("change root for comparison", func() {
@@ -806,6 +839,890 @@ b: bar
 			})
 		})
 
+		Context("comparing Kubernetes resources by identity", func() {
+			It("should compare the items of Kubernetes lists regardless of their position", func() {
+				report, err := CompareResourceSets(
+					[]string{assets("kubernetes-lists", "from.yml")},
+					[]string{assets("kubernetes-lists", "to.yml")},
+					DirectoryFilter{},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.HasDifferences()).To(BeTrue())
+				Expect(len(report.Resources)).To(BeEquivalentTo(2))
+
+				Expect(report.Resources[0].ID.String()).To(Equal("Pod/foobar/foo-1 (v1)"))
+				Expect(report.Resources[0].Kind).To(Equal(MODIFICATION))
+				Expect(len(report.Resources[0].Diffs)).To(BeEquivalentTo(1))
+				Expect(report.Resources[0].Diffs[0]).To(BeSameDiffAs(singleDiff(
+					"/metadata/labels/foo",
+					MODIFICATION,
+					"bAr",
+					"bar",
+				)))
+
+				Expect(report.Resources[1].ID.String()).To(Equal("Pod/foobar/foo-2 (v1)"))
+				Expect(report.Resources[1].Diffs).To(BeEmpty())
+			})
+
+			It("should fail when the same resource is defined more than once", func() {
+				_, err := CompareResourceSets(
+					[]string{assets("kubernetes-lists", "from.yml"), assets("kubernetes-lists", "to.yml")},
+					[]string{assets("kubernetes-lists", "to.yml")},
+					DirectoryFilter{},
+				)
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(ContainSubstring("is defined more than once"))
+			})
+		})
+
+		Context("comparing directories", func() {
+			It("should fail for malformed filter patterns", func() {
+				_, err := NewDirectoryFilter([]string{"*.yml"}, []string{"[abc"})
+				Expect(err).To(MatchError(ContainSubstring("invalid file pattern [abc")))
+
+				_, err = CompareDirectories(assets("kubernetes-lists"), assets("kubernetes-lists"), DirectoryFilter{Include: []string{"["}})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("normalizing Kubernetes default values", func() {
+			manifest := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        image: app:1.0
+        ports:
+        - containerPort: 8080
+`
+
+			live := `---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  revisionHistoryLimit: 10
+  strategy:
+    type: RollingUpdate
+    rollingUpdate:
+      maxSurge: 25%
+      maxUnavailable: 25%
+  template:
+    spec:
+      dnsPolicy: Default
+      restartPolicy: Always
+      containers:
+      - name: app
+        image: app:1.0
+        imagePullPolicy: IfNotPresent
+        terminationMessagePath: /dev/termination-log
+        resources: {}
+        ports:
+        - containerPort: 8080
+          protocol: TCP
+`
+
+			It("should only report values that differ from the default value", func() {
+				results, err := compare(yml(manifest), yml(live), NormalizeDefaults(KubernetesDefaults))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(results)).To(BeEquivalentTo(1))
+				Expect(results[0]).To(BeSameDiffAs(singleDiff(
+					"/spec/template/spec",
+					ADDITION,
+					nil,
+					yml("dnsPolicy: Default"),
+				)))
+			})
+
+			It("should not modify the input documents", func() {
+				from, to := yml(manifest), yml(live)
+				before, err := yamlv3.Marshal(to)
+				Expect(err).ToNot(HaveOccurred())
+
+				report, err := CompareNodes(from, to, NormalizeDefaults(KubernetesDefaults))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(1))
+
+				after, err := yamlv3.Marshal(to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(after)).To(Equal(string(before)))
+				Expect(report.To.Documents[0]).To(BeIdenticalTo(to))
+			})
+
+			It("should remove default values from a copy that keeps aliases", func() {
+				document := yml(`{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "app"}, "spec": {"type": "ClusterIP", "sessionAffinity": "None"}}`)
+				before, err := yamlv3.Marshal(document)
+				Expect(err).ToNot(HaveOccurred())
+
+				result, err := yamlv3.Marshal(RemoveDefaults(document, KubernetesDefaults))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(result)).ToNot(ContainSubstring("ClusterIP"))
+
+				after, err := yamlv3.Marshal(document)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(after)).To(Equal(string(before)))
+
+				anchored := yml("defaults: &defaults\n  type: ClusterIP\nkind: Service\napiVersion: v1\nspec: *defaults\n")
+				result, err = yamlv3.Marshal(RemoveDefaults(anchored, KubernetesDefaults))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(result)).To(ContainSubstring("spec: *defaults"))
+				Expect(string(result)).ToNot(ContainSubstring("ClusterIP"))
+
+				result, err = yamlv3.Marshal(anchored)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(string(result)).To(ContainSubstring("ClusterIP"))
+			})
+
+			It("should report default values without normalization", func() {
+				results, err := compare(yml(manifest), yml(live))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(len(results)).To(BeNumerically(">", 1))
+			})
+
+			It("should support additional rules", func() {
+				results, err := compare(yml(manifest), yml(live), NormalizeDefaults(append(KubernetesDefaults,
+					DefaultRule{Kind: "Deployment", Path: "/spec/template/spec/dnsPolicy", Value: "Default"},
+				)))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(results).To(BeEmpty())
+			})
+		})
+
+		Context("comparing two reports of the same inputs", func() {
+			It("should list new, resolved, and changed differences", func() {
+				previousDiffs, err := compare(yml(`{"a": 1, "b": 2, "c": 3}`), yml(`{"a": 1, "b": 20, "c": 30}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				currentDiffs, err := compare(yml(`{"a": 1, "b": 2, "c": 3}`), yml(`{"a": 10, "b": 2, "c": 300}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				previous, current := Report{Diffs: previousDiffs}, Report{Diffs: currentDiffs}
+
+				delta := DiffReports(previous, current)
+				Expect(delta.HasChanges()).To(BeTrue())
+				Expect(delta.New).To(HaveLen(1))
+				Expect(delta.New[0]).To(BeSameDiffAs(singleDiff("/a", MODIFICATION, 1, 10)))
+				Expect(delta.Resolved).To(HaveLen(1))
+				Expect(delta.Resolved[0]).To(BeSameDiffAs(singleDiff("/b", MODIFICATION, 2, 20)))
+				Expect(delta.Changed).To(HaveLen(1))
+				Expect(delta.Changed[0]).To(BeSameDiffAs(singleDiff("/c", MODIFICATION, 3, 300)))
+
+				Expect(DiffReports(current, current).HasChanges()).To(BeFalse())
+			})
+		})
+
+		Context("applying a baseline of accepted differences", func() {
+			It("should hide accepted differences and list stale baseline entries", func() {
+				diffs, err := compare(
+					yml(`{"image": "app:1.0", "replicas": 1, "name": "app"}`),
+					yml(`{"image": "app:1.1", "replicas": 3, "name": "foo"}`),
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				var baseline Baseline
+				for _, entry := range []BaselineEntry{
+					{Path: "/image", ToRegex: `^app:1\.\d+$`},
+					{Path: "/replicas", To: func(s string) *string { return &s }("2")},
+					{Path: "/name", Reason: "renamed on purpose"},
+					{Path: "/labels"},
+				} {
+					entry, err := NewBaselineEntry(entry)
+					Expect(err).ToNot(HaveOccurred())
+					baseline.Accepted = append(baseline.Accepted, entry)
+				}
+
+				report, stale := Report{Diffs: diffs}.ApplyBaseline(baseline)
+				Expect(report.Diffs).To(HaveLen(1))
+				Expect(report.Diffs[0]).To(BeSameDiffAs(singleDiff("/replicas", MODIFICATION, 1, 3)))
+
+				Expect(stale).To(HaveLen(2))
+				Expect(stale[0].String()).To(Equal("/replicas"))
+				Expect(stale[1].String()).To(Equal("/labels"))
+			})
+
+			It("should match baseline entries only in the named document", func() {
+				from := ytbx.InputFile{Documents: multiDoc("---\nspec: {replicas: 1}\n---\nspec: {replicas: 1}\n")}
+				to := ytbx.InputFile{Documents: multiDoc("---\nspec: {replicas: 2}\n---\nspec: {replicas: 3}\n")}
+				report, err := CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(2))
+
+				var baseline Baseline
+				for _, entry := range []BaselineEntry{
+					{Path: "/spec/replicas", Document: "2"},
+					{Path: "/spec/replicas"},
+				} {
+					entry, err := NewBaselineEntry(entry)
+					Expect(err).ToNot(HaveOccurred())
+					baseline.Accepted = append(baseline.Accepted, entry)
+				}
+
+				result, stale := report.ApplyBaseline(baseline)
+				Expect(result.Diffs).To(HaveLen(1))
+				Expect(result.Diffs[0].Path.DocumentIdx).To(Equal(0))
+
+				Expect(stale).To(HaveLen(1))
+				Expect(stale[0].String()).To(Equal("/spec/replicas"))
+
+				from.Names = []string{"first", "second"}
+				entry, err := NewBaselineEntry(BaselineEntry{Path: "/spec/replicas", Document: "first"})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(entry.String()).To(Equal("/spec/replicas in first"))
+
+				report, err = CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				result, stale = report.ApplyBaseline(Baseline{Accepted: []BaselineEntry{entry}})
+				Expect(stale).To(BeEmpty())
+				Expect(result.Diffs).To(HaveLen(1))
+				Expect(result.Diffs[0].Path.DocumentIdx).To(Equal(1))
+			})
+
+			It("should accept Dot-Style paths of baseline entries", func() {
+				diffs, err := compare(
+					yml(`{"c": {"containers": [{"name": "app", "image": "app:1.0"}, {"name": "proxy", "image": "proxy:1.0"}]}}`),
+					yml(`{"c": {"containers": [{"name": "app", "image": "app:1.1"}, {"name": "proxy", "image": "proxy:1.1"}, {"name": "sidecar", "image": "sidecar:1.0"}]}}`),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(HaveLen(3))
+
+				var baseline Baseline
+				for _, entry := range []BaselineEntry{
+					{Path: "c.containers.app.image"},
+					{Path: "/c/containers/name=proxy/image"},
+					{Path: "c.containers"},
+				} {
+					entry, err := NewBaselineEntry(entry)
+					Expect(err).ToNot(HaveOccurred())
+					baseline.Accepted = append(baseline.Accepted, entry)
+				}
+
+				report, stale := Report{Diffs: diffs}.ApplyBaseline(baseline)
+				Expect(report.Diffs).To(BeEmpty())
+				Expect(stale).To(BeEmpty())
+			})
+
+			It("should match Dot-Style paths of documents that exist on one side only", func() {
+				from := ytbx.InputFile{Documents: []*yamlv3.Node{multiDoc("spec: {replicas: 1}")[0], nil}}
+				to := ytbx.InputFile{Documents: multiDoc("---\nspec: {replicas: 2}\n---\nspec: {replicas: 1}\n")}
+				report, err := CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(2))
+
+				entry, err := NewBaselineEntry(BaselineEntry{Path: "spec.replicas", Document: "1"})
+				Expect(err).ToNot(HaveOccurred())
+
+				other, err := NewBaselineEntry(BaselineEntry{Path: "spec", Document: "2"})
+				Expect(err).ToNot(HaveOccurred())
+
+				result, stale := report.ApplyBaseline(Baseline{Accepted: []BaselineEntry{entry, other}})
+				Expect(result.Diffs).To(HaveLen(1))
+				Expect(result.Diffs[0].Path.DocumentIdx).To(Equal(1))
+				Expect(stale).To(HaveLen(1))
+			})
+
+			It("should match Dot-Style paths in reports loaded from JSON", func() {
+				report, err := CompareInputFiles(
+					InputFileFromNodes("from", yml(`{"c": {"containers": [{"name": "app", "image": "app:1.0"}]}}`)),
+					InputFileFromNodes("to", yml(`{"c": {"containers": [{"name": "app", "image": "app:1.1"}]}}`)),
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				var buf bytes.Buffer
+				Expect((&JSONReport{Report: report}).WriteReport(&buf)).To(Succeed())
+
+				loaded, err := ParseReport(buf.Bytes())
+				Expect(err).ToNot(HaveOccurred())
+
+				entry, err := NewBaselineEntry(BaselineEntry{Path: "c.containers.app.image"})
+				Expect(err).ToNot(HaveOccurred())
+
+				result, stale := loaded.ApplyBaseline(Baseline{Accepted: []BaselineEntry{entry}})
+				Expect(result.Diffs).To(BeEmpty())
+				Expect(stale).To(BeEmpty())
+			})
+
+			It("should fail for invalid baseline entries", func() {
+				_, err := NewBaselineEntry(BaselineEntry{Path: "/image", FromRegex: "("})
+				Expect(err).To(HaveOccurred())
+
+				_, err = NewBaselineEntry(BaselineEntry{})
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("describing change kinds as a bitmask", func() {
+			It("should use stable bits for each change kind", func() {
+				Expect(KindBit(ADDITION)).To(Equal(1))
+				Expect(KindBit(REMOVAL)).To(Equal(2))
+				Expect(KindBit(MODIFICATION)).To(Equal(4))
+				Expect(KindBit(ORDERCHANGE)).To(Equal(8))
+
+				diffs, err := compare(yml(`{"a": 1, "b": 2}`), yml(`{"a": 2, "c": 3}`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(Report{Diffs: diffs}.KindMask()).To(Equal(1 | 2 | 4))
+			})
+
+			It("should find change kinds by name", func() {
+				kind, err := KindByName("order-change")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(kind).To(Equal(ORDERCHANGE))
+
+				_, err = KindByName("rename")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("classifying differences with a policy", func() {
+			var policy = func(rules ...PolicyRule) Policy {
+				var result Policy
+				for _, rule := range rules {
+					rule, err := NewPolicyRule(rule)
+					Expect(err).ToNot(HaveOccurred())
+					result.Rules = append(result.Rules, rule)
+				}
+
+				return result
+			}
+
+			It("should set the highest severity of all matching rules", func() {
+				diffs, err := compare(
+					yml(`{"spec": {"replicas": 3, "containers": [{"name": "app", "image": "app:1.0", "securityContext": {"runAsNonRoot": true}}]}}`),
+					yml(`{"spec": {"replicas": 2, "containers": [{"name": "app", "image": "app:1.1"}]}}`),
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				report := Report{Diffs: diffs}.Classify(policy(
+					PolicyRule{Path: "/spec/containers/*/image", Severity: "info"},
+					PolicyRule{Path: "/spec/replicas", Change: "decrease", Severity: "warning"},
+					PolicyRule{Path: "/spec/replicas", Change: "increase", Severity: "error"},
+					PolicyRule{Path: "/**/securityContext", Kind: "removal", Severity: "error"},
+				))
+
+				severities := map[string]Severity{}
+				for _, diff := range report.Diffs {
+					severities[diff.Path.ToGoPatchStyle()] = diff.Severity
+				}
+
+				Expect(severities).To(Equal(map[string]Severity{
+					"/spec/replicas":                  SeverityWarning,
+					"/spec/containers/name=app":       SeverityError,
+					"/spec/containers/name=app/image": SeverityInfo,
+				}))
+
+				Expect(report.MaxSeverity()).To(Equal(SeverityError))
+			})
+
+			It("should match keys that contain a slash using an escaped path", func() {
+				diffs, err := compare(
+					yml(`{"metadata": {"labels": {"app.kubernetes.io/name": "foo", "app.kubernetes.io/version": "1.0"}}}`),
+					yml(`{"metadata": {"labels": {"app.kubernetes.io/name": "bar", "app.kubernetes.io/version": "1.1"}}}`),
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				report := Report{Diffs: diffs}.Classify(policy(
+					PolicyRule{Path: "/metadata/labels/app.kubernetes.io~1name", Severity: "error"},
+					PolicyRule{Path: "/metadata/labels/app.kubernetes.io", Severity: "warning"},
+				))
+
+				severities := map[string]Severity{}
+				for _, diff := range report.Diffs {
+					severities[diff.Path.PathElements[len(diff.Path.PathElements)-1].Name] = diff.Severity
+				}
+
+				Expect(severities).To(Equal(map[string]Severity{
+					"app.kubernetes.io/name":    SeverityError,
+					"app.kubernetes.io/version": SeverityNone,
+				}))
+			})
+
+			It("should fail for invalid policy rules", func() {
+				for _, rule := range []PolicyRule{
+					{Path: "/spec"},
+					{Path: "/spec", Severity: "fatal"},
+					{Path: "/spec", Severity: "info", Kind: "rename"},
+					{Path: "/spec", Severity: "info", Change: "double"},
+					{Path: "/spec", Severity: "info", ToRegex: "("},
+				} {
+					_, err := NewPolicyRule(rule)
+					Expect(err).To(HaveOccurred())
+				}
+			})
+		})
+
+		Context("comparing with a context", func() {
+			It("should stop in case the context is cancelled", func() {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+
+				_, err := CompareInputFilesContext(ctx,
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"a": 1}`)}},
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"a": 2}`)}},
+				)
+				Expect(err).To(MatchError(context.Canceled))
+			})
+
+			It("should report the progress of the comparison", func() {
+				var progress []Progress
+				_, err := CompareInputFilesContext(context.Background(),
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"a": 1}`), yml(`{"b": 1}`)}},
+					ytbx.InputFile{Documents: []*yamlv3.Node{yml(`{"a": 2}`), yml(`{"b": 2}`)}},
+					ProgressCallback(func(p Progress) { progress = append(progress, p) }),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(progress).To(HaveLen(2))
+				Expect(progress[1].Documents).To(Equal(2))
+				Expect(progress[1].DocumentsDone).To(Equal(2))
+				Expect(progress[1].NodesCompared).To(BeNumerically(">", progress[0].NodesCompared))
+			})
+
+			It("should return an error instead of panicking for nodes that cannot be hashed", func() {
+				list := func(values ...string) *yamlv3.Node {
+					node := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: "!!seq"}
+					for _, value := range values {
+						node.Content = append(node.Content, &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{
+							{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: value},
+						}})
+					}
+
+					return node
+				}
+
+				_, err := compare(list("a", "b"), list("b", "c"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("comparing concurrently", func() {
+			It("should return the same differences in the same order as the sequential comparison", func() {
+				document := func(version int) *yamlv3.Node {
+					var buf bytes.Buffer
+					buf.WriteString("items:\n")
+					for i := 0; i < 200; i++ {
+						fmt.Fprintf(&buf, "- name: item-%d\n  version: %d\n  values: [%d, %d, %d]\n  spec:\n", i, version*(i%3), i, version, i%2)
+						for j := 0; j < 10; j++ {
+							fmt.Fprintf(&buf, "    key%d: value-%d\n", j, (i+j+version)%4)
+						}
+					}
+
+					return yml(buf.String())
+				}
+
+				from := ytbx.InputFile{Documents: []*yamlv3.Node{document(1), document(2), document(3)}}
+				to := ytbx.InputFile{Documents: []*yamlv3.Node{document(2), document(3), document(3)}}
+
+				sequential, err := CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(sequential.Diffs).ToNot(BeEmpty())
+
+				concurrent, err := CompareInputFiles(from, to, Workers(4))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(concurrent.Diffs).To(HaveLen(len(sequential.Diffs)))
+				for i := range sequential.Diffs {
+					Expect(concurrent.Diffs[i]).To(BeSameDiffAs(sequential.Diffs[i]))
+				}
+
+				var sequentialProgress, concurrentProgress Progress
+				_, err = CompareInputFiles(from, to, ProgressCallback(func(p Progress) { sequentialProgress = p }))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = CompareInputFiles(from, to, Workers(4), ProgressCallback(func(p Progress) {
+					if p.DocumentsDone == p.Documents {
+						concurrentProgress = p
+					}
+				}))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(concurrentProgress.DocumentsDone).To(Equal(3))
+				Expect(concurrentProgress.NodesCompared).To(Equal(sequentialProgress.NodesCompared))
+			})
+		})
+
+		Context("comparing list entries by their content", func() {
+			It("should not modify the input when order changes are ignored", func() {
+				from := yml(`{"list": [[3, 1, 2], [5, 4]]}`)
+				to := yml(`{"list": [[1, 2, 3], [4, 5], [6]]}`)
+
+				diffs, err := compare(from, to, IgnoreOrderChanges(true))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0].Details).To(HaveLen(1))
+				Expect(diffs[0].Details[0].Kind).To(Equal(ADDITION))
+
+				Expect(from).To(BeEquivalentTo(yml(`{"list": [[3, 1, 2], [5, 4]]}`)))
+			})
+
+			It("should use the configured order of nested lists", func() {
+				from := yml(`{"list": [[3, 1, 2], [5, 4]]}`)
+				to := yml(`{"list": [[1, 2, 3], [4, 5]]}`)
+
+				diffs, err := compare(from, to, IgnoreOrderChanges(true), HashSequenceOrder(SequenceOrderSensitive))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0].Details).To(HaveLen(2))
+
+				diffs, err = compare(from, to, HashSequenceOrder(SequenceOrderInsensitive))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(BeEmpty())
+			})
+
+			It("should support aliases that refer to one of their parents", func() {
+				diffs, err := compare(
+					yml("list:\n- &x {value: a, self: *x}\n- {value: b}\n- {value: c}\n"),
+					yml("list:\n- &x {value: a, self: *x}\n- {value: c}\n"),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0].Details).To(HaveLen(1))
+				Expect(diffs[0].Details[0].Kind).To(Equal(REMOVAL))
+			})
+		})
+
+		Context("comparing in-memory data", func() {
+			type container struct {
+				Name  string `yaml:"name"`
+				Image string `yaml:"image"`
+			}
+
+			type config struct {
+				Replicas   int         `yaml:"replicas"`
+				Containers []container `yaml:"containers"`
+			}
+
+			It("should compare Go values", func() {
+				report, err := CompareValues(
+					config{Replicas: 1, Containers: []container{{Name: "app", Image: "app:1.0"}}},
+					config{Replicas: 1, Containers: []container{{Name: "app", Image: "app:1.1"}}},
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.From.Location).To(Equal("from"))
+				Expect(report.To.Location).To(Equal("to"))
+				Expect(report.From.Note).To(Equal("Go value of type dyff_test.config"))
+				Expect(report.Diffs).To(HaveLen(1))
+				Expect(report.Diffs[0]).To(BeSameDiffAs(singleDiff("/containers/name=app/image", MODIFICATION, "app:1.0", "app:1.1")))
+			})
+
+			It("should fail for Go values that cannot be converted", func() {
+				_, err := CompareValues(func() {}, 42)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should compare YAML nodes", func() {
+				report, err := CompareNodes(yml(`{"a": 1, "b": 2}`), yml(`{"a": 1, "b": 3}`))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(1))
+				Expect(report.Diffs[0]).To(BeSameDiffAs(singleDiff("/b", MODIFICATION, 2, 3)))
+			})
+
+			It("should create input files from bytes with a display name", func() {
+				from, err := InputFileFromBytes("rendered (before)", []byte("---\na: 1\n---\nb: 1\n"))
+				Expect(err).ToNot(HaveOccurred())
+				Expect(from.Location).To(Equal("rendered (before)"))
+				Expect(from.Documents).To(HaveLen(2))
+
+				to, err := InputFileFromBytes("rendered (after)", []byte(`{"a": 1}`+"\n"+`{"b": 2}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				report, err := CompareInputFiles(from, to)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(report.Diffs).To(HaveLen(1))
+				Expect(report.Diffs[0].Path.ToGoPatchStyle()).To(Equal("/b"))
+
+				_, err = InputFileFromBytes("empty", []byte("  \n"))
+				Expect(err).To(HaveOccurred())
+			})
+		})
+
+		Context("comparing with custom comparators", func() {
+			It("should use a comparator registered for a path pattern", func() {
+				diffs, err := compare(
+					yml(`{"hosts": [{"name": "a", "host": "Example.COM"}, {"name": "b", "host": "foo.example.com"}]}`),
+					yml(`{"hosts": [{"name": "a", "host": "example.com"}, {"name": "b", "host": "bar.example.com"}]}`),
+					PathComparator("/hosts/*/host", ScalarEquality(strings.EqualFold)),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0]).To(BeSameDiffAs(singleDiff("/hosts/name=b/host", MODIFICATION, "foo.example.com", "bar.example.com")))
+			})
+
+			It("should use a comparator registered for a tag to create custom details", func() {
+				var paths []string
+				comparator := ComparatorFunc(func(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Detail, bool, error) {
+					paths = append(paths, path.ToGoPatchStyle())
+					if strings.TrimSuffix(from.Value, "/32") == strings.TrimSuffix(to.Value, "/32") {
+						return nil, true, nil
+					}
+
+					return []Detail{{Kind: MODIFICATION, From: from, To: to}}, true, nil
+				})
+
+				diffs, err := compare(
+					yml("allow: !cidr 10.0.0.1\ndeny: !cidr 10.0.0.2/32\nlist: !cidr 10.0.0.0/8\n"),
+					yml("allow: !cidr 10.0.0.1/32\ndeny: !cidr 10.0.0.2\nlist: !cidr 10.0.0.0/16\n"),
+					TagComparator("!cidr", comparator),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(paths).To(Equal([]string{"/allow", "/deny", "/list"}))
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0].Path.ToGoPatchStyle()).To(Equal("/list"))
+			})
+
+			It("should not use comparators for added or removed entries", func() {
+				var paths []string
+				diffs, err := compare(
+					yml(`{"keep": !cidr 10.0.0.1, "old": !cidr 10.0.0.2}`),
+					yml(`{"keep": !cidr 10.0.0.1, "new": !cidr 10.0.0.3}`),
+					TagComparator("!cidr", ComparatorFunc(func(path ytbx.Path, _ *yamlv3.Node, _ *yamlv3.Node) ([]Detail, bool, error) {
+						paths = append(paths, path.ToGoPatchStyle())
+						return nil, true, nil
+					})),
+				)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(paths).To(Equal([]string{"/keep"}))
+				Expect(diffs).To(HaveLen(1))
+				Expect(diffs[0].Details).To(HaveLen(2))
+			})
+
+			It("should return errors of comparators", func() {
+				_, err := compare(yml(`{"a": 1}`), yml(`{"a": 2}`),
+					PathComparator("/a", ComparatorFunc(func(ytbx.Path, *yamlv3.Node, *yamlv3.Node) ([]Detail, bool, error) {
+						return nil, false, fmt.Errorf("failed to compare")
+					})),
+				)
+				Expect(err).To(MatchError("failed to compare"))
+			})
+		})
+
+		Context("working with the differences of a report", func() {
+			var report Report
+
+			BeforeEach(func() {
+				diffs, err := compare(
+					yml(`{"spec": {"replicas": 1, "list": [1, 2, 3], "old": true}, "metadata": {"name": "foo"}}`),
+					yml(`{"spec": {"replicas": 2, "list": [3, 2, 1], "new": true}, "metadata": {"name": "bar"}}`),
+				)
+				Expect(err).ToNot(HaveOccurred())
+
+				report = Report{Diffs: diffs}
+			})
+
+			It("should call the callbacks per change kind", func() {
+				counts := map[rune]int{}
+				count := func(kind rune) func(Diff, Detail) error {
+					return func(_ Diff, detail Detail) error {
+						Expect(detail.Kind).To(Equal(kind))
+						counts[kind]++
+						return nil
+					}
+				}
+
+				Expect(report.Visit(Visitor{
+					Addition:     count(ADDITION),
+					Removal:      count(REMOVAL),
+					Modification: count(MODIFICATION),
+				})).To(Succeed())
+
+				Expect(counts).To(Equal(map[rune]int{ADDITION: 1, REMOVAL: 1, MODIFICATION: 2}))
+
+				Expect(report.Visit(Visitor{OrderChange: func(Diff, Detail) error {
+					return fmt.Errorf("order changes are not allowed")
+				}})).To(MatchError("order changes are not allowed"))
+			})
+
+			It("should group the differences", func() {
+				groups := report.GroupByPathPrefix(1)
+				Expect(groups).To(HaveLen(2))
+				Expect(groups[0].Key).To(Equal("/spec"))
+				Expect(groups[0].Diffs).To(HaveLen(3))
+				Expect(groups[1].Key).To(Equal("/metadata"))
+				Expect(groups[1].Diffs).To(HaveLen(1))
+
+				Expect(report.GroupByDocument()).To(HaveLen(1))
+				Expect(report.GroupByDocument()[0].Key).To(Equal("document #1"))
+			})
+
+			It("should sort the differences by path", func() {
+				var paths []string
+				for _, diff := range report.SortByPath().Diffs {
+					paths = append(paths, diff.Path.ToGoPatchStyle())
+				}
+
+				Expect(paths).To(Equal([]string{"/metadata/name", "/spec", "/spec/list", "/spec/replicas"}))
+			})
+
+			It("should map, filter, and partition the differences", func() {
+				kinds := func(diff Diff) bool {
+					for _, detail := range diff.Details {
+						if detail.Kind == MODIFICATION {
+							return true
+						}
+					}
+
+					return false
+				}
+
+				modifications, rest := report.Partition(kinds)
+				Expect(modifications.Diffs).To(HaveLen(2))
+				Expect(rest.Diffs).To(HaveLen(2))
+				Expect(report.FilterFunc(kinds)).To(Equal(modifications))
+
+				classified := report.Map(func(diff Diff) Diff {
+					diff.Severity = SeverityWarning
+					return diff
+				})
+				Expect(classified.MaxSeverity()).To(Equal(SeverityWarning))
+				Expect(report.MaxSeverity()).To(Equal(SeverityNone))
+			})
+		})
+
+		Context("inverting, composing, and merging reports", func() {
+			var a, b, c *yamlv3.Node
+
+			BeforeEach(func() {
+				a = yml(`{"name": "foo", "list": [1, 2], "old": true}`)
+				b = yml(`{"name": "bar", "list": [1, 2, 3], "new": true}`)
+				c = yml(`{"name": "foo", "list": [1, 2, 3, 4], "old": true}`)
+			})
+
+			It("should invert a report", func() {
+				forward, err := CompareNodes(a, b)
+				Expect(err).ToNot(HaveOccurred())
+
+				backward, err := CompareNodes(b, a)
+				Expect(err).ToNot(HaveOccurred())
+
+				inverted := forward.Invert()
+				Expect(inverted.From.Location).To(Equal("to"))
+				Expect(inverted.To.Location).To(Equal("from"))
+				Expect(inverted.Diffs).To(HaveLen(len(backward.Diffs)))
+				for _, diff := range inverted.Diffs {
+					Expect(backward.Diffs).To(ContainElement(BeSameDiffAs(diff)))
+				}
+
+				Expect(inverted.Invert().Diffs).To(Equal(forward.Diffs))
+			})
+
+			It("should compose two consecutive reports", func() {
+				first, err := CompareNodes(a, b)
+				Expect(err).ToNot(HaveOccurred())
+
+				second, err := CompareNodes(b, c)
+				Expect(err).ToNot(HaveOccurred())
+
+				composed, err := Compose(first, second)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(composed.From.Documents[0]).To(Equal(a))
+				Expect(composed.To.Documents[0]).To(Equal(c))
+				Expect(composed.Diffs).To(HaveLen(1))
+				Expect(composed.Diffs[0]).To(BeSameDiffAs(singleDiff("/list", ADDITION, nil, list(`[3, 4]`))))
+
+				reverted, err := Compose(first, first.Invert())
+				Expect(err).ToNot(HaveOccurred())
+				Expect(reverted.Diffs).To(BeEmpty())
+			})
+
+			It("should compose reports into the same report as a direct comparison", func() {
+				for _, inputs := range [][3]string{
+					{`{"spec": {"x": {"y": 1, "z": 1}, "keep": 1}}`, `{"spec": {"x": {"y": 2, "z": 1}, "keep": 1}}`, `{"spec": {"keep": 1}}`},
+					{`{"list": ["a", "b"]}`, `{"list": ["b", "a", "c"]}`, `{"list": ["c", "b", "a"]}`},
+					{`{"a": 1, "b": .inf}`, `{"a": 2, "b": .nan}`, `{"a": 1, "b": .inf}`},
+				} {
+					a, b, c := InputFileFromNodes("a", yml(inputs[0])), InputFileFromNodes("b", yml(inputs[1])), InputFileFromNodes("c", yml(inputs[2]))
+
+					first, err := CompareInputFiles(a, b)
+					Expect(err).ToNot(HaveOccurred())
+
+					second, err := CompareInputFiles(b, c)
+					Expect(err).ToNot(HaveOccurred())
+
+					direct, err := CompareInputFiles(a, c)
+					Expect(err).ToNot(HaveOccurred())
+
+					composed, err := Compose(first, second)
+					Expect(err).ToNot(HaveOccurred())
+					Expect(composed.Diffs).To(HaveLen(len(direct.Diffs)))
+					for i := range direct.Diffs {
+						Expect(composed.Diffs[i]).To(BeSameDiffAs(direct.Diffs[i]))
+					}
+				}
+			})
+
+			It("should use the values of the first input for removed entries", func() {
+				first, err := CompareNodes(yml(`{"spec": {"x": {"y": 1, "z": 1}, "keep": 1}}`), yml(`{"spec": {"x": {"y": 2, "z": 1}, "keep": 1}}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				second, err := CompareNodes(yml(`{"spec": {"x": {"y": 2, "z": 1}, "keep": 1}}`), yml(`{"spec": {"keep": 1}}`))
+				Expect(err).ToNot(HaveOccurred())
+
+				composed, err := Compose(first, second)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(composed.Diffs).To(HaveLen(1))
+				Expect(composed.Diffs[0]).To(BeSameDiffAs(singleDiff("/spec", REMOVAL, yml(`{"x": {"y": 1, "z": 1}}`), nil)))
+			})
+
+			It("should fail to compose reports without documents or of different inputs", func() {
+				first, err := CompareInputFiles(InputFileFromNodes("a", yml(`{"a": 1}`)), InputFileFromNodes("b", yml(`{"a": 2}`)))
+				Expect(err).ToNot(HaveOccurred())
+
+				second, err := CompareInputFiles(InputFileFromNodes("b", yml(`{"a": 2}`)), InputFileFromNodes("c", yml(`{"a": 3}`)))
+				Expect(err).ToNot(HaveOccurred())
+
+				var buf bytes.Buffer
+				Expect((&JSONReport{Report: second}).WriteReport(&buf)).To(Succeed())
+
+				loaded, err := ParseReport(buf.Bytes())
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = Compose(first, loaded)
+				Expect(err).To(MatchError(ContainSubstring("the documents of c are not available")))
+
+				other, err := CompareInputFiles(InputFileFromNodes("x", yml(`{"a": 5}`)), InputFileFromNodes("c", yml(`{"a": 3}`)))
+				Expect(err).ToNot(HaveOccurred())
+
+				_, err = Compose(first, other)
+				Expect(err).To(MatchError(ContainSubstring("the first report ends with b, but the second report starts with x")))
+			})
+
+			It("should fail to compose reports of a different number of documents", func() {
+				_, err := Compose(
+					Report{To: InputFileFromNodes("one", a)},
+					Report{From: InputFileFromNodes("two", a, b)},
+				)
+				Expect(err).To(HaveOccurred())
+			})
+
+			It("should merge reports of multiple file pairs", func() {
+				first, err := CompareInputFiles(InputFileFromNodes("a.yml", a), InputFileFromNodes("b.yml", b))
+				Expect(err).ToNot(HaveOccurred())
+
+				second, err := CompareInputFiles(InputFileFromNodes("b.yml", b), InputFileFromNodes("c.yml", c))
+				Expect(err).ToNot(HaveOccurred())
+
+				merged := first.Merge(second)
+				Expect(merged.From.Location).To(Equal("a.yml, b.yml"))
+				Expect(merged.From.Documents).To(HaveLen(2))
+				Expect(merged.Diffs).To(HaveLen(len(first.Diffs) + len(second.Diffs)))
+
+				for i, diff := range merged.Diffs {
+					original := first.Diffs
+					if i >= len(first.Diffs) {
+						original = second.Diffs
+						Expect(diff.Path.DocumentIdx).To(Equal(1))
+						Expect(diff.Path.RootDescription()).To(Equal("b.yml"))
+					} else {
+						Expect(diff.Path.DocumentIdx).To(Equal(0))
+						Expect(diff.Path.RootDescription()).To(Equal("a.yml"))
+					}
+
+					Expect(original).To(ContainElement(BeSameDiffAs(diff)))
+				}
+			})
+		})
+
 		Context("checking known issues of compare", func() {
 			It("should not return order change differences in case the named-entry list does not have unique identifiers", func() {
 				from, to, err := ytbx.LoadFiles("../../assets/issues/issue-38/from.yml", "../../assets/issues/issue-38/to.yml")
@@ -0,0 +1,226 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// Invert returns the report of the comparison in the opposite direction, with
+// from and to swapped, where additions become removals and vice versa
+func (r Report) Invert() Report {
+	result := Report{
+		From:  r.To,
+		To:    r.From,
+		Diffs: make([]Diff, len(r.Diffs)),
+	}
+
+	for i, diff := range r.Diffs {
+		details := make([]Detail, len(diff.Details))
+		for j, detail := range diff.Details {
+			details[j] = Detail{Kind: detail.Kind, From: detail.To, To: detail.From}
+			switch detail.Kind {
+			case ADDITION:
+				details[j].Kind = REMOVAL
+
+			case REMOVAL:
+				details[j].Kind = ADDITION
+			}
+		}
+
+		diff.Path.Root = &result.From
+		diff.Details = sortDetails(details)
+		result.Diffs[i] = diff
+	}
+
+	return result
+}
+
+// Compose combines the report of a comparison from A to B with the report of a
+// comparison from B to C into the report of the effective differences from A
+// to C. Since changes of the second report can replace or remove the parents
+// of changes of the first report, and the removed values have to be the ones
+// of A, the differences are not derived from the two reports, but from the
+// documents of A and C, which both reports already hold, so that no input
+// file is loaded again. Use the same compare options as for the reports. The
+// severities of a policy have to be applied to the result again. Reports that
+// were loaded from JSON do not contain the documents and cannot be composed.
+func Compose(first Report, second Report, compareOptions ...CompareOption) (Report, error) {
+	if len(first.To.Documents) != len(second.From.Documents) {
+		return Report{}, fmt.Errorf("unable to compose reports, the first report ends with %d documents, but the second report starts with %d documents",
+			len(first.To.Documents),
+			len(second.From.Documents),
+		)
+	}
+
+	for _, input := range []ytbx.InputFile{first.From, second.To} {
+		if !hasDocuments(input) {
+			return Report{}, fmt.Errorf("unable to compose reports, the documents of %s are not available, for example because the report was loaded from JSON",
+				inputName(input),
+			)
+		}
+	}
+
+	same, err := sameInputFile(first.To, second.From)
+	if err != nil {
+		return Report{}, err
+	}
+
+	if !same {
+		return Report{}, fmt.Errorf("unable to compose reports, the first report ends with %s, but the second report starts with %s",
+			inputName(first.To),
+			inputName(second.From),
+		)
+	}
+
+	return CompareInputFiles(first.From, second.To, compareOptions...)
+}
+
+// hasDocuments checks whether the input file contains at least one document,
+// where reports loaded from JSON only contain nil placeholder documents
+func hasDocuments(input ytbx.InputFile) bool {
+	for _, document := range input.Documents {
+		if document != nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// inputName returns the location of the input file for messages
+func inputName(input ytbx.InputFile) string {
+	if input.Location == "" {
+		return "an unnamed input"
+	}
+
+	return input.Location
+}
+
+// sameInputFile checks whether both input files have the same content, or in
+// case the documents are not available, whether they have the same location
+func sameInputFile(a ytbx.InputFile, b ytbx.InputFile) (bool, error) {
+	if !hasDocuments(a) || !hasDocuments(b) {
+		return a.Location != "" && a.Location == b.Location, nil
+	}
+
+	report, err := CompareInputFiles(a, b)
+	if err != nil {
+		return false, err
+	}
+
+	return len(report.Diffs) == 0, nil
+}
+
+// Merge combines the report with the reports of other file pairs into one
+// report, where the documents of all inputs are listed one after another and
+// the paths of the differences refer to the respective combined document
+func (r Report) Merge(others ...Report) Report {
+	if len(others) == 0 {
+		return r
+	}
+
+	reports := append([]Report{r}, others...)
+	result := Report{
+		From: mergeInputFiles(reports, func(r Report) ytbx.InputFile { return r.From }),
+		To:   mergeInputFiles(reports, func(r Report) ytbx.InputFile { return r.To }),
+	}
+
+	var offset int
+	for _, report := range reports {
+		for _, diff := range report.Diffs {
+			diff.Path.Root = &result.From
+			diff.Path.DocumentIdx += offset
+			result.Diffs = append(result.Diffs, diff)
+		}
+
+		offset += len(report.From.Documents)
+	}
+
+	return result
+}
+
+// mergeInputFiles lists the documents of the input files one after another,
+// the documents are named so that they can still be told apart
+func mergeInputFiles(reports []Report, inputFile func(Report) ytbx.InputFile) ytbx.InputFile {
+	var (
+		result    ytbx.InputFile
+		locations []string
+		notes     []string
+	)
+
+	for _, report := range reports {
+		input := inputFile(report)
+		locations = appendUnique(locations, input.Location)
+		notes = appendUnique(notes, input.Note)
+
+		for idx := range input.Documents {
+			var name string
+			switch {
+			case idx < len(input.Names):
+				name = input.Names[idx]
+
+			case len(input.Documents) == 1:
+				name = input.Location
+
+			default:
+				name = fmt.Sprintf("%s, document #%d", input.Location, idx+1)
+			}
+
+			result.Names = append(result.Names, name)
+		}
+
+		result.Documents = append(result.Documents, input.Documents...)
+	}
+
+	result.Location = strings.Join(locations, ", ")
+	result.Note = strings.Join(notes, ", ")
+	return result
+}
+
+func appendUnique(list []string, value string) []string {
+	if value == "" {
+		return list
+	}
+
+	for _, entry := range list {
+		if entry == value {
+			return list
+		}
+	}
+
+	return append(list, value)
+}
+
+// sortDetails sorts the details in the order in which a comparison reports
+// them: order changes, removals, additions, and then modifications
+func sortDetails(details []Detail) []Detail {
+	rank := map[rune]int{ORDERCHANGE: 0, REMOVAL: 1, ADDITION: 2, MODIFICATION: 3}
+	sort.SliceStable(details, func(i, j int) bool {
+		return rank[details[i].Kind] < rank[details[j].Kind]
+	})
+
+	return details
+}
@@ -21,15 +21,13 @@
 package dyff
 
 import (
+	"context"
 	"fmt"
-	"sort"
 	"strings"
 
 	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/text"
-	"github.com/gonvenience/wrap"
 	"github.com/gonvenience/ytbx"
-	"github.com/mitchellh/hashstructure"
 	yamlv3 "gopkg.in/yaml.v3"
 )
 
@@ -40,10 +38,20 @@ type compareSettings struct {
 	NonStandardIdentifierGuessCountThreshold int
 	IgnoreOrderChanges                       bool
 	KubernetesEntityDetection                bool
+	DefaultRules                             []DefaultRule
+	ProgressCallback                         func(Progress)
+	Workers                                  int
+	SequenceOrder                            SequenceOrder
+	Comparators                              []registeredComparator
 }
 
 type compare struct {
-	settings compareSettings
+	settings      compareSettings
+	ctx           context.Context
+	progress      *progressTracker
+	hasher        *nodeHasher
+	workers       chan struct{}
+	largeSubtrees map[*yamlv3.Node]struct{}
 }
 
 // ListItemIdentifierField names the field that identifies a list.
@@ -75,16 +83,33 @@ func KubernetesEntityDetection(value bool) CompareOption {
 	}
 }
 
+// ProgressCallback sets a function that is called with the progress of the
+// comparison, for example to show a progress indicator for huge documents
+func ProgressCallback(callback func(Progress)) CompareOption {
+	return func(settings *compareSettings) {
+		settings.ProgressCallback = callback
+	}
+}
+
 // CompareInputFiles is one of the convenience main entry points for comparing
 // objects. In this case the representation of an input file, which might
 // contain multiple documents. It returns a report with the list of differences.
 func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ...CompareOption) (Report, error) {
+	return CompareInputFilesContext(context.Background(), from, to, compareOptions...)
+}
+
+// CompareInputFilesContext compares the two input files like CompareInputFiles,
+// but stops with the error of the context in case the context is cancelled or
+// its deadline is exceeded before the comparison is finished.
+func CompareInputFilesContext(ctx context.Context, from ytbx.InputFile, to ytbx.InputFile, compareOptions ...CompareOption) (Report, error) {
 	if len(from.Documents) != len(to.Documents) {
 		return Report{}, fmt.Errorf("comparing YAMLs with a different number of documents is currently not supported")
 	}
 
 	// initialize the comparator with the tool defaults
 	compare := compare{
+		ctx:      ctx,
+		progress: &progressTracker{documents: len(from.Documents)},
 		settings: compareSettings{
 			NonStandardIdentifierGuessCountThreshold: 3,
 			IgnoreOrderChanges:                       false,
@@ -97,36 +122,76 @@ func CompareInputFiles(from ytbx.InputFile, to ytbx.InputFile, compareOptions ..
 		compareOption(&compare.settings)
 	}
 
-	result := make([]Diff, 0)
+	// the default values are removed from copies of the documents, so that the
+	// report still refers to the unmodified input documents
+	fromDocuments, toDocuments := from.Documents, to.Documents
+	if len(compare.settings.DefaultRules) > 0 {
+		fromDocuments = make([]*yamlv3.Node, len(from.Documents))
+		toDocuments = make([]*yamlv3.Node, len(to.Documents))
+		for idx := range from.Documents {
+			fromDocuments[idx] = RemoveDefaults(from.Documents[idx], compare.settings.DefaultRules)
+			toDocuments[idx] = RemoveDefaults(to.Documents[idx], compare.settings.DefaultRules)
+		}
+	}
+
+	if compare.settings.Workers > 1 {
+		compare.workers = make(chan struct{}, compare.settings.Workers)
+		compare.largeSubtrees = map[*yamlv3.Node]struct{}{}
+		for idx := range fromDocuments {
+			recordLargeSubtrees(fromDocuments[idx], compare.largeSubtrees)
+			recordLargeSubtrees(toDocuments[idx], compare.largeSubtrees)
+		}
+	}
+
+	compare.hasher = newNodeHasher(compare.workers != nil)
+
+	documents := make([]comparison, len(from.Documents))
 	for idx := range from.Documents {
-		diffs, err := compare.objects(
-			ytbx.Path{
+		documents[idx] = comparison{
+			path: ytbx.Path{
 				Root:        &from,
 				DocumentIdx: idx,
 			},
-			from.Documents[idx],
-			to.Documents[idx],
-		)
-
-		if err != nil {
-			return Report{}, err
+			from: fromDocuments[idx],
+			to:   toDocuments[idx],
 		}
+	}
 
-		result = append(result, diffs...)
+	result, err := compare.all(documents, func(comparison) bool { return true }, func() {
+		compare.progress.documentDone(compare.settings.ProgressCallback)
+	})
+
+	if err != nil {
+		return Report{}, err
 	}
 
 	return Report{from, to, result}, nil
 }
 
 func (compare *compare) objects(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
+	// checking the context for every node would synchronize the workers,
+	// therefore it is only checked for the first node and then periodically
+	if compare.progress.nodeCompared(compare.settings.ProgressCallback)%progressInterval == 1 {
+		if err := compare.ctx.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(compare.settings.Comparators) > 0 {
+		diffs, handled, err := compare.customComparison(path, from, to)
+		if err != nil || handled {
+			return diffs, err
+		}
+	}
+
 	switch {
 	case from == nil && to == nil:
 		return []Diff{}, nil
 
 	case (from == nil && to != nil) || (from != nil && to == nil):
 		return []Diff{{
-			path,
-			[]Detail{{
+			Path: path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -135,8 +200,8 @@ func (compare *compare) objects(path ytbx.Path, from *yamlv3.Node, to *yamlv3.No
 
 	case (from.Kind != to.Kind) || (from.Tag != to.Tag):
 		return []Diff{{
-			path,
-			[]Detail{{
+			Path: path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -172,8 +237,8 @@ func (compare *compare) nonNilSameKindNodes(path ytbx.Path, from *yamlv3.Node, t
 		default:
 			if from.Value != to.Value {
 				diffs, err = []Diff{{
-					path,
-					[]Detail{{
+					Path: path,
+					Details: []Detail{{
 						Kind: MODIFICATION,
 						From: from,
 						To:   to,
@@ -193,25 +258,19 @@ func (compare *compare) nonNilSameKindNodes(path ytbx.Path, from *yamlv3.Node, t
 }
 
 func (compare *compare) mappingNodes(path ytbx.Path, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
-	result := make([]Diff, 0)
 	removals := []*yamlv3.Node{}
 	additions := []*yamlv3.Node{}
+	common := []comparison{}
 
 	for i := 0; i < len(from.Content); i += 2 {
 		key, fromItem := from.Content[i], from.Content[i+1]
 		if toItem, ok := findValueByKey(to, key.Value); ok {
 			// `from` and `to` contain the same `key` -> require comparison
-			diffs, err := compare.objects(
-				ytbx.NewPathWithNamedElement(path, key.Value),
-				followAlias(fromItem),
-				followAlias(toItem),
-			)
-
-			if err != nil {
-				return nil, err
-			}
-
-			result = append(result, diffs...)
+			common = append(common, comparison{
+				path: ytbx.NewPathWithNamedElement(path, key.Value),
+				from: followAlias(fromItem),
+				to:   followAlias(toItem),
+			})
 
 		} else {
 			// `from` contain the `key`, but `to` does not -> removal
@@ -227,6 +286,11 @@ func (compare *compare) mappingNodes(path ytbx.Path, from *yamlv3.Node, to *yaml
 		}
 	}
 
+	result, err := compare.all(common, compare.largeSubtree, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	diff := Diff{Path: path, Details: []Detail{}}
 
 	if len(removals) > 0 {
@@ -309,15 +373,26 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 		)
 	}
 
-	fromLookup := compare.createLookUpMap(from)
-	toLookup := compare.createLookUpMap(to)
+	fromLookup, err := compare.createLookUpMap(from)
+	if err != nil {
+		return nil, err
+	}
+
+	toLookup, err := compare.createLookUpMap(to)
+	if err != nil {
+		return nil, err
+	}
 
 	// Fill two lists with the hashes of the entries of each list
 	fromCommon := make([]*yamlv3.Node, 0, fromLength)
 	toCommon := make([]*yamlv3.Node, 0, toLength)
 
 	for idxPos, fromValue := range from.Content {
-		hash := compare.calcNodeHash(fromValue)
+		hash, err := compare.calcNodeHash(fromValue)
+		if err != nil {
+			return nil, err
+		}
+
 		_, ok := toLookup[hash]
 		if ok {
 			fromCommon = append(fromCommon, fromValue)
@@ -331,7 +406,12 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 		case len(fromLookup[hash]) > len(toLookup[hash]):
 			// `from` entry exists in `to` list, but there are duplicates and
 			// the number of duplicates is smaller
-			if !compare.hasEntry(removals, from.Content[idxPos]) {
+			found, err := compare.hasEntry(removals, from.Content[idxPos])
+			if err != nil {
+				return nil, err
+			}
+
+			if !found {
 				for i := 0; i < len(fromLookup[hash])-len(toLookup[hash]); i++ {
 					removals = append(removals, from.Content[idxPos])
 				}
@@ -340,7 +420,11 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 	}
 
 	for idxPos, toValue := range to.Content {
-		hash := compare.calcNodeHash(toValue)
+		hash, err := compare.calcNodeHash(toValue)
+		if err != nil {
+			return nil, err
+		}
+
 		_, ok := fromLookup[hash]
 		if ok {
 			toCommon = append(toCommon, toValue)
@@ -354,7 +438,12 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 		case len(fromLookup[hash]) < len(toLookup[hash]):
 			// `to` entry exists in `from` list, but there are duplicates and
 			// the number of duplicates is increased
-			if !compare.hasEntry(additions, to.Content[idxPos]) {
+			found, err := compare.hasEntry(additions, to.Content[idxPos])
+			if err != nil {
+				return nil, err
+			}
+
+			if !found {
 				for i := 0; i < len(toLookup[hash])-len(fromLookup[hash]); i++ {
 					additions = append(additions, to.Content[idxPos])
 				}
@@ -364,7 +453,9 @@ func (compare *compare) simpleLists(path ytbx.Path, from *yamlv3.Node, to *yamlv
 
 	var orderChanges []Detail
 	if !compare.settings.IgnoreOrderChanges {
-		orderChanges = compare.findOrderChangesInSimpleList(fromCommon, toCommon)
+		if orderChanges, err = compare.findOrderChangesInSimpleList(fromCommon, toCommon); err != nil {
+			return nil, err
+		}
 	}
 
 	return packChangesAndAddToResult([]Diff{}, path, orderChanges, additions, removals)
@@ -386,8 +477,7 @@ func nameFromPath(node *yamlv3.Node, field ListItemIdentifierField) (string, err
 func (compare *compare) namedEntryLists(path ytbx.Path, identifier ListItemIdentifierField, from *yamlv3.Node, to *yamlv3.Node) ([]Diff, error) {
 	removals := make([]*yamlv3.Node, 0)
 	additions := make([]*yamlv3.Node, 0)
-
-	result := make([]Diff, 0)
+	common := make([]comparison, 0)
 
 	// Fill two lists with the names of the entries that are common in both lists
 	fromLength := len(from.Content)
@@ -404,15 +494,11 @@ func (compare *compare) namedEntryLists(path ytbx.Path, identifier ListItemIdent
 
 		if toEntry, ok := getEntryFromNamedList(to, identifier, name); ok {
 			// `from` and `to` have the same entry identified by identifier and name -> require comparison
-			diffs, err := compare.objects(
-				ytbx.NewPathWithNamedListElement(path, identifier, name),
-				followAlias(fromEntry),
-				followAlias(toEntry),
-			)
-			if err != nil {
-				return nil, err
-			}
-			result = append(result, diffs...)
+			common = append(common, comparison{
+				path: ytbx.NewPathWithNamedListElement(path, identifier, name),
+				from: followAlias(fromEntry),
+				to:   followAlias(toEntry),
+			})
 			fromNames = append(fromNames, name)
 
 		} else {
@@ -438,6 +524,11 @@ func (compare *compare) namedEntryLists(path ytbx.Path, identifier ListItemIdent
 		}
 	}
 
+	result, err := compare.all(common, compare.largeSubtree, nil)
+	if err != nil {
+		return nil, err
+	}
+
 	var orderChanges []Detail
 	if !compare.settings.IgnoreOrderChanges {
 		orderChanges = findOrderChangesInNamedEntryLists(fromNames, toNames)
@@ -450,8 +541,8 @@ func (compare *compare) nodeValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3
 	result := make([]Diff, 0)
 	if strings.Compare(from.Value, to.Value) != 0 {
 		result = append(result, Diff{
-			path,
-			[]Detail{{
+			Path: path,
+			Details: []Detail{{
 				Kind: MODIFICATION,
 				From: from,
 				To:   to,
@@ -462,35 +553,54 @@ func (compare *compare) nodeValues(path ytbx.Path, from *yamlv3.Node, to *yamlv3
 	return result, nil
 }
 
-func (compare *compare) findOrderChangesInSimpleList(fromCommon, toCommon []*yamlv3.Node) []Detail {
+func (compare *compare) findOrderChangesInSimpleList(fromCommon, toCommon []*yamlv3.Node) ([]Detail, error) {
 	// Try to find order changes ...
 	if len(fromCommon) == len(toCommon) {
 		for idx := range fromCommon {
-			if compare.calcNodeHash(fromCommon[idx]) != compare.calcNodeHash(toCommon[idx]) {
+			fromHash, err := compare.calcNodeHash(fromCommon[idx])
+			if err != nil {
+				return nil, err
+			}
+
+			toHash, err := compare.calcNodeHash(toCommon[idx])
+			if err != nil {
+				return nil, err
+			}
+
+			if fromHash != toHash {
 				return []Detail{{
 					Kind: ORDERCHANGE,
 					From: &yamlv3.Node{Kind: yamlv3.SequenceNode, Content: fromCommon},
 					To:   &yamlv3.Node{Kind: yamlv3.SequenceNode, Content: toCommon},
-				}}
+				}}, nil
 			}
 		}
 	}
 
-	return []Detail{}
+	return []Detail{}, nil
 }
 
 // hasEntry returns whether the given node is in the provided list. Not exactly
 // a fast or efficient way to verify that a node is already in a list, but
 // given that this should rarely be used it is ok for now.
-func (compare *compare) hasEntry(list []*yamlv3.Node, searchEntry *yamlv3.Node) bool {
-	var searchEntryHash = compare.calcNodeHash(searchEntry)
+func (compare *compare) hasEntry(list []*yamlv3.Node, searchEntry *yamlv3.Node) (bool, error) {
+	searchEntryHash, err := compare.calcNodeHash(searchEntry)
+	if err != nil {
+		return false, err
+	}
+
 	for _, listEntry := range list {
-		if searchEntryHash == compare.calcNodeHash(listEntry) {
-			return true
+		listEntryHash, err := compare.calcNodeHash(listEntry)
+		if err != nil {
+			return false, err
+		}
+
+		if searchEntryHash == listEntryHash {
+			return true, nil
 		}
 	}
 
-	return false
+	return false, nil
 }
 
 // AsSequenceNode translates a string list into a SequenceNode
@@ -754,10 +864,14 @@ func getNonStandardIdentifierFromNamedLists(listA, listB *yamlv3.Node, nonStanda
 	return ""
 }
 
-func (compare *compare) createLookUpMap(sequenceNode *yamlv3.Node) map[uint64][]int {
+func (compare *compare) createLookUpMap(sequenceNode *yamlv3.Node) (map[uint64][]int, error) {
 	result := make(map[uint64][]int, len(sequenceNode.Content))
 	for idx, entry := range sequenceNode.Content {
-		hash := compare.calcNodeHash(entry)
+		hash, err := compare.calcNodeHash(entry)
+		if err != nil {
+			return nil, err
+		}
+
 		if _, ok := result[hash]; !ok {
 			result[hash] = []int{}
 		}
@@ -765,90 +879,7 @@ func (compare *compare) createLookUpMap(sequenceNode *yamlv3.Node) map[uint64][]
 		result[hash] = append(result[hash], idx)
 	}
 
-	return result
-}
-
-func (compare *compare) basicType(node *yamlv3.Node) interface{} {
-	switch node.Kind {
-	case yamlv3.DocumentNode:
-		panic("document nodes are not supported to be translated into a basic type")
-
-	case yamlv3.MappingNode:
-		result := map[interface{}]interface{}{}
-		for i := 0; i < len(node.Content); i += 2 {
-			k, v := followAlias(node.Content[i]), followAlias(node.Content[i+1])
-			result[compare.basicType(k)] = compare.basicType(v)
-		}
-
-		return result
-
-	case yamlv3.SequenceNode:
-		result := []interface{}{}
-
-		if compare.settings.IgnoreOrderChanges {
-			sortNode(node)
-		}
-
-		for _, entry := range node.Content {
-			result = append(result, compare.basicType(followAlias(entry)))
-		}
-
-		return result
-
-	case yamlv3.ScalarNode:
-		return node.Value
-
-	case yamlv3.AliasNode:
-		return compare.basicType(node.Alias)
-
-	default:
-		panic("should be unreachable")
-	}
-}
-
-func (compare *compare) calcNodeHash(node *yamlv3.Node) (hash uint64) {
-	var err error
-
-	switch node.Kind {
-	case yamlv3.MappingNode, yamlv3.SequenceNode:
-		hash, err = hashstructure.Hash(compare.basicType(node), nil)
-
-	case yamlv3.ScalarNode:
-		hash, err = hashstructure.Hash(node.Value, nil)
-
-	case yamlv3.AliasNode:
-		hash = compare.calcNodeHash(followAlias(node))
-
-	default:
-		err = fmt.Errorf("kind %v is not supported", node.Kind)
-	}
-
-	if err != nil {
-		panic(wrap.Errorf(err, "failed to calculate hash of %#v", node.Value))
-	}
-
-	return hash
-}
-
-func sortNode(node *yamlv3.Node) {
-	sort.Slice(node.Content, func(i, j int) bool {
-		a, b := node.Content[i], node.Content[j]
-
-		if a.Kind != b.Kind {
-			return a.Kind < b.Kind
-		}
-
-		if a.Tag != b.Tag {
-			return strings.Compare(a.Tag, b.Tag) < 0
-		}
-
-		switch a.Kind {
-		case yamlv3.ScalarNode:
-			return strings.Compare(a.Value, b.Value) < 0
-		}
-
-		return len(a.Content) < len(b.Content)
-	})
+	return result, nil
 }
 
 func min(a, b int) int {
@@ -0,0 +1,256 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// DefaultRule describes a field of a Kubernetes resource that is set to a
+// default value by the API server in case it is not specified. The path uses
+// slashes to separate the keys, where a star matches all entries of a list or
+// map, for example: /spec/template/spec/containers/*/imagePullPolicy. An empty
+// API version or kind matches all resources.
+type DefaultRule struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Path       string      `yaml:"path"`
+	Value      interface{} `yaml:"value"`
+}
+
+// KubernetesDefaults is the built-in list of commonly used default values that
+// are set by the Kubernetes API server
+var KubernetesDefaults = func() []DefaultRule {
+	var rules []DefaultRule
+
+	for _, workload := range []struct {
+		apiVersion string
+		kind       string
+		prefix     string
+	}{
+		{"v1", "Pod", "/spec"},
+		{"apps/v1", "Deployment", "/spec/template/spec"},
+		{"apps/v1", "StatefulSet", "/spec/template/spec"},
+		{"apps/v1", "DaemonSet", "/spec/template/spec"},
+		{"apps/v1", "ReplicaSet", "/spec/template/spec"},
+		{"batch/v1", "Job", "/spec/template/spec"},
+		{"batch/v1", "CronJob", "/spec/jobTemplate/spec/template/spec"},
+	} {
+		for path, value := range map[string]interface{}{
+			"/dnsPolicy":                     "ClusterFirst",
+			"/restartPolicy":                 "Always",
+			"/schedulerName":                 "default-scheduler",
+			"/securityContext":               map[string]interface{}{},
+			"/terminationGracePeriodSeconds": 30,
+		} {
+			rules = append(rules, DefaultRule{workload.apiVersion, workload.kind, workload.prefix + path, value})
+		}
+
+		for _, containers := range []string{"/containers/*", "/initContainers/*"} {
+			for path, value := range map[string]interface{}{
+				"/imagePullPolicy":          "IfNotPresent",
+				"/terminationMessagePath":   "/dev/termination-log",
+				"/terminationMessagePolicy": "File",
+				"/resources":                map[string]interface{}{},
+				"/ports/*/protocol":         "TCP",
+			} {
+				rules = append(rules, DefaultRule{workload.apiVersion, workload.kind, workload.prefix + containers + path, value})
+			}
+		}
+	}
+
+	return append(rules,
+		DefaultRule{"apps/v1", "Deployment", "/spec/revisionHistoryLimit", 10},
+		DefaultRule{"apps/v1", "Deployment", "/spec/progressDeadlineSeconds", 600},
+		DefaultRule{"apps/v1", "Deployment", "/spec/strategy", map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"maxSurge": "25%", "maxUnavailable": "25%"},
+		}},
+		DefaultRule{"apps/v1", "StatefulSet", "/spec/revisionHistoryLimit", 10},
+		DefaultRule{"apps/v1", "StatefulSet", "/spec/podManagementPolicy", "OrderedReady"},
+		DefaultRule{"apps/v1", "StatefulSet", "/spec/updateStrategy", map[string]interface{}{
+			"type":          "RollingUpdate",
+			"rollingUpdate": map[string]interface{}{"partition": 0},
+		}},
+		DefaultRule{"apps/v1", "DaemonSet", "/spec/revisionHistoryLimit", 10},
+		DefaultRule{"batch/v1", "Job", "/spec/backoffLimit", 6},
+		DefaultRule{"batch/v1", "Job", "/spec/completions", 1},
+		DefaultRule{"batch/v1", "Job", "/spec/parallelism", 1},
+		DefaultRule{"batch/v1", "CronJob", "/spec/concurrencyPolicy", "Allow"},
+		DefaultRule{"batch/v1", "CronJob", "/spec/failedJobsHistoryLimit", 1},
+		DefaultRule{"batch/v1", "CronJob", "/spec/successfulJobsHistoryLimit", 3},
+		DefaultRule{"batch/v1", "CronJob", "/spec/suspend", false},
+		DefaultRule{"v1", "Service", "/spec/type", "ClusterIP"},
+		DefaultRule{"v1", "Service", "/spec/sessionAffinity", "None"},
+		DefaultRule{"v1", "Service", "/spec/ports/*/protocol", "TCP"},
+	)
+}()
+
+// LoadDefaultRules reads a file with additional default value rules, which
+// uses the same fields as DefaultRule in a list under the key defaults
+func LoadDefaultRules(location string) ([]DefaultRule, error) {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return nil, err
+	}
+
+	var file struct {
+		Defaults []DefaultRule `yaml:"defaults"`
+	}
+
+	if err := yamlv3.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse default rules file %s: %w", location, err)
+	}
+
+	for _, rule := range file.Defaults {
+		if strings.Trim(rule.Path, "/") == "" {
+			return nil, fmt.Errorf("failed to parse default rules file %s, there is a rule without a path", location)
+		}
+	}
+
+	return file.Defaults, nil
+}
+
+// NormalizeDefaults enables the removal of fields from Kubernetes resources,
+// that are set to the default value according to the provided rules, before
+// the comparison. This way, a difference is only reported in case the value
+// differs from the effective default value.
+func NormalizeDefaults(rules []DefaultRule) CompareOption {
+	return func(settings *compareSettings) {
+		settings.DefaultRules = rules
+	}
+}
+
+// RemoveDefaults returns a copy of the Kubernetes resource document without
+// the fields that are set to the default value according to the provided
+// rules. Items of a Kubernetes List are processed individually. The provided
+// document is not modified.
+func RemoveDefaults(document *yamlv3.Node, rules []DefaultRule) *yamlv3.Node {
+	result := deepCopyNode(document, map[*yamlv3.Node]*yamlv3.Node{})
+	removeDefaults(result, rules)
+	return result
+}
+
+// removeDefaults removes the fields with default values in place
+func removeDefaults(document *yamlv3.Node, rules []DefaultRule) {
+	node := followAlias(documentContent(document))
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return
+	}
+
+	if IsKubernetesList(node) {
+		if items, ok := findValueByKey(node, "items"); ok && items.Kind == yamlv3.SequenceNode {
+			for _, item := range items.Content {
+				removeDefaults(item, rules)
+			}
+		}
+
+		return
+	}
+
+	var apiVersion, kind string
+	if value, ok := findValueByKey(node, "apiVersion"); ok {
+		apiVersion = value.Value
+	}
+
+	if value, ok := findValueByKey(node, "kind"); ok {
+		kind = value.Value
+	}
+
+	for _, rule := range rules {
+		if (rule.APIVersion != "" && rule.APIVersion != apiVersion) || (rule.Kind != "" && rule.Kind != kind) {
+			continue
+		}
+
+		removeDefault(node, strings.Split(strings.Trim(rule.Path, "/"), "/"), rule.Value)
+	}
+}
+
+// deepCopyNode copies the node and all of its children, where aliases refer to
+// the copy of their anchor so that the copy does not share any nodes with the
+// original
+func deepCopyNode(node *yamlv3.Node, copies map[*yamlv3.Node]*yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	if result, ok := copies[node]; ok {
+		return result
+	}
+
+	result := &yamlv3.Node{}
+	copies[node] = result
+	*result = *node
+
+	if node.Alias != nil {
+		result.Alias = deepCopyNode(node.Alias, copies)
+	}
+
+	if node.Content != nil {
+		result.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, entry := range node.Content {
+			result.Content[i] = deepCopyNode(entry, copies)
+		}
+	}
+
+	return result
+}
+
+// removeDefault follows the path elements and removes the map entry at the end
+// of the path in case it has the default value
+func removeDefault(node *yamlv3.Node, elements []string, value interface{}) {
+	node = followAlias(node)
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if key := followAlias(node.Content[i]).Value; elements[0] != "*" && elements[0] != key {
+				continue
+			}
+
+			if len(elements) > 1 {
+				removeDefault(node.Content[i+1], elements[1:], value)
+				continue
+			}
+
+			if equalsJSON(value, followAlias(node.Content[i+1])) {
+				node.Content = append(node.Content[:i], node.Content[i+2:]...)
+				i -= 2
+			}
+		}
+
+	case yamlv3.SequenceNode:
+		if len(elements) == 1 {
+			return
+		}
+
+		for idx, item := range node.Content {
+			if elements[0] == "*" || elements[0] == strconv.Itoa(idx) {
+				removeDefault(item, elements[1:], value)
+			}
+		}
+	}
+}
@@ -0,0 +1,144 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gonvenience/text"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ReportDelta lists how the differences of two reports of the same inputs
+// changed, for example between two runs over time
+type ReportDelta struct {
+	New      []Diff
+	Resolved []Diff
+	Changed  []Diff
+}
+
+// DeltaSummary is a reporter that prints a short list of the differences that
+// are new, resolved, or changed
+type DeltaSummary struct {
+	ReportDelta
+
+	UseGoPatchPaths bool
+}
+
+// DiffReports compares the differences of the previous report with the
+// differences of the current report. Differences are matched by their path,
+// changed differences are the ones with the same path but different details.
+func DiffReports(previous Report, current Report) ReportDelta {
+	previousDiffs := make(map[string]Diff, len(previous.Diffs))
+	for _, diff := range previous.Diffs {
+		previousDiffs[diffKey(diff)] = diff
+	}
+
+	currentDiffs := make(map[string]Diff, len(current.Diffs))
+	for _, diff := range current.Diffs {
+		currentDiffs[diffKey(diff)] = diff
+	}
+
+	var result ReportDelta
+	for _, diff := range current.Diffs {
+		previousDiff, ok := previousDiffs[diffKey(diff)]
+		switch {
+		case !ok:
+			result.New = append(result.New, diff)
+
+		case diffFingerprint(previousDiff) != diffFingerprint(diff):
+			result.Changed = append(result.Changed, diff)
+		}
+	}
+
+	for _, diff := range previous.Diffs {
+		if _, ok := currentDiffs[diffKey(diff)]; !ok {
+			result.Resolved = append(result.Resolved, diff)
+		}
+	}
+
+	return result
+}
+
+// HasChanges returns whether there are any new, resolved, or changed
+// differences
+func (delta ReportDelta) HasChanges() bool {
+	return len(delta.New)+len(delta.Resolved)+len(delta.Changed) > 0
+}
+
+// WriteReport writes the list of new, resolved, and changed differences to
+// the provided writer
+func (summary *DeltaSummary) WriteReport(out io.Writer) error {
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	writer.WriteString(fmt.Sprintf("%s, %s, and %s\n",
+		text.Plural(len(summary.New), "new difference"),
+		text.Plural(len(summary.Resolved), "resolved difference"),
+		text.Plural(len(summary.Changed), "changed difference"),
+	))
+
+	for _, entry := range []struct {
+		diffs  []Diff
+		marker string
+	}{
+		{summary.New, green("%c new      ", ADDITION)},
+		{summary.Resolved, red("%c resolved ", REMOVAL)},
+		{summary.Changed, yellow("%s changed  ", indicator(MODIFICATION))},
+	} {
+		for _, diff := range entry.diffs {
+			writer.WriteString(fmt.Sprintf("  %s %s\n", entry.marker, summary.location(diff)))
+		}
+	}
+
+	return nil
+}
+
+func (summary *DeltaSummary) location(diff Diff) string {
+	showPathRoot := diff.Path.Root != nil && (len(diff.Path.Root.Documents) > 1 || len(diff.Path.Root.Names) > 0)
+	return pathToString(diff.Path, summary.UseGoPatchPaths, showPathRoot)
+}
+
+// diffKey returns a string that identifies the location of the difference,
+// which is the document (by name if available) and the path in the document
+func diffKey(diff Diff) string {
+	return diff.Path.RootDescription() + "\x00" + diff.Path.ToGoPatchStyle()
+}
+
+// diffFingerprint returns a string representation of the details of the
+// difference, which can be used to check whether two differences are equal
+// independent of the YAML style that is used for the values
+func diffFingerprint(diff Diff) string {
+	var buf strings.Builder
+	for _, detail := range diff.Details {
+		buf.WriteRune(detail.Kind)
+		for _, node := range []*yamlv3.Node{detail.From, detail.To} {
+			data, _ := nodeToJSON(node)
+			buf.Write(data)
+			buf.WriteRune(0)
+		}
+	}
+
+	return buf.String()
+}
@@ -0,0 +1,242 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
+
+// DirectoryFilter defines which files are considered when comparing
+// directories. Patterns are matched against the path relative to the
+// directory, as well as against the file name only.
+type DirectoryFilter struct {
+	Include []string
+	Exclude []string
+}
+
+// NewDirectoryFilter returns a filter with the include and exclude patterns,
+// which fails if one of the patterns is malformed
+func NewDirectoryFilter(include []string, exclude []string) (DirectoryFilter, error) {
+	filter := DirectoryFilter{Include: include, Exclude: exclude}
+	return filter, filter.validate()
+}
+
+// FileReport is the comparison result of one file in a directory comparison,
+// where the kind is ADDITION or REMOVAL for files that only exist in one of
+// the directories, and MODIFICATION for files that exist in both. In case a
+// file that exists in both directories cannot be compared, for example due to
+// a different number of documents, the error is recorded for that file.
+type FileReport struct {
+	Path string
+	Kind rune
+	Err  error
+
+	Report
+}
+
+// DirectoryReport is the comparison result of two directory trees with one
+// file report per relative file path
+type DirectoryReport struct {
+	From  string
+	To    string
+	Files []FileReport
+}
+
+// HasDifferences returns whether files were added or removed, or whether
+// files that exist in both directories have differences
+func (r DirectoryReport) HasDifferences() bool {
+	for _, file := range r.Files {
+		if file.Kind != MODIFICATION || len(file.Diffs) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Errors returns the errors of the files that could not be compared
+func (r DirectoryReport) Errors() []error {
+	var result []error
+	for _, file := range r.Files {
+		if file.Err != nil {
+			result = append(result, file.Err)
+		}
+	}
+
+	return result
+}
+
+// CompareDirectories compares all YAML and JSON files of two directory trees,
+// which are paired by their path relative to the respective directory. Files
+// with other extensions are not considered. Files that cannot be loaded or
+// compared are recorded with their error, and do not stop the comparison.
+func CompareDirectories(from string, to string, filter DirectoryFilter, compareOptions ...CompareOption) (DirectoryReport, error) {
+	if err := filter.validate(); err != nil {
+		return DirectoryReport{}, err
+	}
+
+	fromFiles, err := listDocumentFiles(from, filter)
+	if err != nil {
+		return DirectoryReport{}, err
+	}
+
+	toFiles, err := listDocumentFiles(to, filter)
+	if err != nil {
+		return DirectoryReport{}, err
+	}
+
+	paths := make([]string, 0, len(fromFiles)+len(toFiles))
+	for path := range fromFiles {
+		paths = append(paths, path)
+	}
+
+	for path := range toFiles {
+		if _, ok := fromFiles[path]; !ok {
+			paths = append(paths, path)
+		}
+	}
+
+	sort.Strings(paths)
+
+	result := DirectoryReport{From: from, To: to}
+	for _, path := range paths {
+		_, inFrom := fromFiles[path]
+		_, inTo := toFiles[path]
+
+		switch {
+		case inFrom && !inTo:
+			result.Files = append(result.Files, FileReport{Path: path, Kind: REMOVAL})
+
+		case !inFrom && inTo:
+			result.Files = append(result.Files, FileReport{Path: path, Kind: ADDITION})
+
+		default:
+			file := FileReport{Path: path, Kind: MODIFICATION}
+			fromFile, toFile, err := ytbx.LoadFiles(filepath.Join(from, path), filepath.Join(to, path))
+			if err != nil {
+				file.Err = fmt.Errorf("failed to load %s: %w", path, err)
+			} else if file.Report, err = CompareInputFiles(fromFile, toFile, compareOptions...); err != nil {
+				file.Err = fmt.Errorf("failed to compare %s: %w", path, err)
+			}
+
+			result.Files = append(result.Files, file)
+		}
+	}
+
+	return result, nil
+}
+
+// listFiles returns the set of paths (relative to the given directory) of all
+// regular files in the directory tree that match the provided filter
+func listFiles(dir string, filter DirectoryFilter) (map[string]struct{}, error) {
+	result := map[string]struct{}{}
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+
+		if filter.Matches(rel) {
+			result[rel] = struct{}{}
+		}
+
+		return nil
+	})
+
+	return result, err
+}
+
+// listDocumentFiles returns the set of paths of the YAML and JSON files in
+// the directory tree that match the provided filter
+func listDocumentFiles(dir string, filter DirectoryFilter) (map[string]struct{}, error) {
+	paths, err := listFiles(dir, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	for path := range paths {
+		if !IsDocumentFile(path) {
+			delete(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+// IsDocumentFile checks whether the file extension refers to a YAML or JSON file
+func IsDocumentFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yml", ".yaml", ".json":
+		return true
+	}
+
+	return false
+}
+
+// validate checks that all patterns of the filter are well-formed, since a
+// malformed pattern would otherwise silently match nothing
+func (filter DirectoryFilter) validate() error {
+	for _, pattern := range append(append([]string{}, filter.Include...), filter.Exclude...) {
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid file pattern %s: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// Matches returns whether the file with the path (relative to the directory)
+// is included and not excluded by the filter
+func (filter DirectoryFilter) Matches(path string) bool {
+	matchesAny := func(patterns []string) bool {
+		for _, pattern := range patterns {
+			if ok, _ := filepath.Match(pattern, path); ok {
+				return true
+			}
+
+			if ok, _ := filepath.Match(pattern, filepath.Base(path)); ok {
+				return true
+			}
+		}
+
+		return false
+	}
+
+	if len(filter.Include) > 0 && !matchesAny(filter.Include) {
+		return false
+	}
+
+	return !matchesAny(filter.Exclude)
+}
@@ -0,0 +1,236 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gonvenience/wrap"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Markers to distinguish the different node kinds in the hash input
+const (
+	scalarHashMarker uint64 = iota + 1
+	mappingHashMarker
+	sequenceHashMarker
+	cycleHashMarker
+)
+
+// SequenceOrder defines whether the order of the entries of a list matters
+// when list entries are compared by their hash, for example to find the same
+// entry in another list
+type SequenceOrder int
+
+// Supported sequence orders, where the default follows the IgnoreOrderChanges
+// setting, so that nested lists are order-insensitive if order changes are
+// ignored
+const (
+	SequenceOrderDefault SequenceOrder = iota
+	SequenceOrderSensitive
+	SequenceOrderInsensitive
+)
+
+// HashSequenceOrder sets whether the order of the entries in nested lists
+// matters when list entries are compared by their content
+func HashSequenceOrder(order SequenceOrder) CompareOption {
+	return func(settings *compareSettings) {
+		settings.SequenceOrder = order
+	}
+}
+
+// nodeHasher calculates the hashes of nodes bottom-up from the hashes of the
+// child nodes. The hashes of mappings and sequences are memoised so that each
+// of them is only hashed once, even if it is part of multiple list comparisons.
+// Scalars are cheaper to hash again than to look up. The lock is only used in
+// case the hasher is used concurrently.
+type nodeHasher struct {
+	sync.Mutex
+	concurrent bool
+	hashes     map[*yamlv3.Node]uint64
+}
+
+func newNodeHasher(concurrent bool) *nodeHasher {
+	return &nodeHasher{
+		concurrent: concurrent,
+		hashes:     map[*yamlv3.Node]uint64{},
+	}
+}
+
+func (hasher *nodeHasher) lookup(node *yamlv3.Node) (uint64, bool) {
+	if hasher.concurrent {
+		hasher.Lock()
+		defer hasher.Unlock()
+	}
+
+	hash, ok := hasher.hashes[node]
+	return hash, ok
+}
+
+func (hasher *nodeHasher) store(node *yamlv3.Node, hash uint64) {
+	if hasher.concurrent {
+		hasher.Lock()
+		defer hasher.Unlock()
+	}
+
+	hasher.hashes[node] = hash
+}
+
+func (compare *compare) calcNodeHash(node *yamlv3.Node) (uint64, error) {
+	hash, _, err := compare.hashNode(node, nil)
+	if err != nil {
+		return 0, wrap.Errorf(err, "failed to calculate hash of %#v", node.Value)
+	}
+
+	return hash, nil
+}
+
+func (compare *compare) orderInsensitiveSequences() bool {
+	switch compare.settings.SequenceOrder {
+	case SequenceOrderSensitive:
+		return false
+
+	case SequenceOrderInsensitive:
+		return true
+	}
+
+	return compare.settings.IgnoreOrderChanges
+}
+
+// hashNode returns the hash of the node without modifying it. The order of
+// keys in mappings does not matter, the order of sequence entries depends on
+// the sequence order setting. The aliases contain the anchors that are
+// currently hashed, so that an alias that refers to one of its parents
+// results in a fixed hash instead of an endless recursion. Since the hash of
+// nodes that are part of such a cycle depends on where the cycle was entered,
+// these are reported back and not memoised.
+func (compare *compare) hashNode(node *yamlv3.Node, aliases map[*yamlv3.Node]struct{}) (uint64, bool, error) {
+	if node.Kind == yamlv3.ScalarNode {
+		return hashString(scalarHashMarker, node.Value), false, nil
+	}
+
+	if hash, ok := compare.hasher.lookup(node); ok {
+		return hash, false, nil
+	}
+
+	var hash uint64
+	var cyclic bool
+
+	switch node.Kind {
+	case yamlv3.MappingNode:
+		// The sum of the hashes of all key/value pairs does not depend on the order
+		var sum uint64
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, keyCyclic, err := compare.hashNode(node.Content[i], aliases)
+			if err != nil {
+				return 0, false, err
+			}
+
+			value, valueCyclic, err := compare.hashNode(node.Content[i+1], aliases)
+			if err != nil {
+				return 0, false, err
+			}
+
+			sum += combineHashes(key, value)
+			cyclic = cyclic || keyCyclic || valueCyclic
+		}
+
+		hash = combineHashes(mappingHashMarker, sum)
+
+	case yamlv3.SequenceNode:
+		ordered := !compare.orderInsensitiveSequences()
+
+		var sum uint64
+		hash = sequenceHashMarker
+		for _, entry := range node.Content {
+			entryHash, entryCyclic, err := compare.hashNode(entry, aliases)
+			if err != nil {
+				return 0, false, err
+			}
+
+			if ordered {
+				hash = combineHashes(hash, entryHash)
+			} else {
+				sum += mixHash(entryHash)
+			}
+
+			cyclic = cyclic || entryCyclic
+		}
+
+		if !ordered {
+			hash = combineHashes(hash, sum)
+		}
+
+	case yamlv3.AliasNode:
+		if _, ok := aliases[node.Alias]; ok {
+			return cycleHashMarker, true, nil
+		}
+
+		if aliases == nil {
+			aliases = map[*yamlv3.Node]struct{}{}
+		}
+
+		aliases[node.Alias] = struct{}{}
+		defer delete(aliases, node.Alias)
+
+		return compare.hashNode(node.Alias, aliases)
+
+	default:
+		return 0, false, fmt.Errorf("kind %v is not supported", node.Kind)
+	}
+
+	if !cyclic {
+		compare.hasher.store(node, hash)
+	}
+
+	return hash, cyclic, nil
+}
+
+// hashString returns the FNV-1a hash of the marker and the string
+func hashString(marker uint64, value string) uint64 {
+	const offset, prime = 14695981039346656037, 1099511628211
+
+	hash := uint64(offset)
+	hash ^= marker
+	hash *= prime
+	for i := 0; i < len(value); i++ {
+		hash ^= uint64(value[i])
+		hash *= prime
+	}
+
+	return mixHash(hash)
+}
+
+// combineHashes returns a hash of the two hashes, where the order matters
+func combineHashes(seed uint64, value uint64) uint64 {
+	return mixHash(seed ^ (value + 0x9e3779b97f4a7c15 + (seed << 6) + (seed >> 2)))
+}
+
+// mixHash spreads the bits of the hash (finalizer of SplitMix64)
+func mixHash(hash uint64) uint64 {
+	hash ^= hash >> 30
+	hash *= 0xbf58476d1ce4e5b9
+	hash ^= hash >> 27
+	hash *= 0x94d049bb133111eb
+	hash ^= hash >> 31
+	return hash
+}
@@ -0,0 +1,177 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ServerManagedFields are the paths of Kubernetes resource fields that are
+// maintained by the API server and not by the user
+var ServerManagedFields = []string{
+	"/metadata/managedFields",
+	"/metadata/resourceVersion",
+	"/metadata/uid",
+	"/metadata/generation",
+	"/metadata/creationTimestamp",
+	"/status",
+}
+
+// StripServerManagedFields removes all fields listed in ServerManagedFields
+// from the provided Kubernetes resource document
+func StripServerManagedFields(document *yamlv3.Node) {
+	for _, path := range ServerManagedFields {
+		deleteMappingEntry(documentContent(document), strings.Split(strings.Trim(path, "/"), "/")...)
+	}
+}
+
+// deleteMappingEntry removes the entry addressed by the keys of nested mapping
+// nodes, entries that do not exist are ignored
+func deleteMappingEntry(node *yamlv3.Node, keys ...string) {
+	if node == nil || node.Kind != yamlv3.MappingNode || len(keys) == 0 {
+		return
+	}
+
+	for i := 0; i < len(node.Content); i += 2 {
+		if followAlias(node.Content[i]).Value != keys[0] {
+			continue
+		}
+
+		if len(keys) == 1 {
+			node.Content = append(node.Content[:i], node.Content[i+2:]...)
+			return
+		}
+
+		deleteMappingEntry(followAlias(node.Content[i+1]), keys[1:]...)
+		return
+	}
+}
+
+// CompareKubectlDiffDirectories compares the two directories that are created
+// by `kubectl diff` for the live and the merged state of the resources. Files
+// are matched by their name, server managed fields are removed, and the result
+// is one combined report with one document per file name.
+func CompareKubectlDiffDirectories(from string, to string, compareOptions ...CompareOption) (Report, error) {
+	fromDocuments, err := loadKubectlDiffDirectory(from)
+	if err != nil {
+		return Report{}, err
+	}
+
+	toDocuments, err := loadKubectlDiffDirectory(to)
+	if err != nil {
+		return Report{}, err
+	}
+
+	names := make([]string, 0, len(fromDocuments)+len(toDocuments))
+	for name := range fromDocuments {
+		names = append(names, name)
+	}
+
+	for name := range toDocuments {
+		if _, ok := fromDocuments[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	result := Report{
+		From: ytbx.InputFile{Location: from, Names: names, Documents: make([]*yamlv3.Node, len(names))},
+		To:   ytbx.InputFile{Location: to, Names: names, Documents: make([]*yamlv3.Node, len(names))},
+	}
+
+	for idx, name := range names {
+		result.From.Documents[idx] = fromDocuments[name]
+		result.To.Documents[idx] = toDocuments[name]
+	}
+
+	for idx, name := range names {
+		fromDocument, toDocument := result.From.Documents[idx], result.To.Documents[idx]
+		path := ytbx.Path{Root: &result.From, DocumentIdx: idx}
+
+		switch {
+		case fromDocument == nil:
+			result.Diffs = append(result.Diffs, Diff{Path: path, Details: []Detail{{Kind: ADDITION, To: documentContent(toDocument)}}})
+
+		case toDocument == nil:
+			result.Diffs = append(result.Diffs, Diff{Path: path, Details: []Detail{{Kind: REMOVAL, From: documentContent(fromDocument)}}})
+
+		default:
+			report, err := CompareInputFiles(
+				ytbx.InputFile{Location: from, Documents: []*yamlv3.Node{fromDocument}},
+				ytbx.InputFile{Location: to, Documents: []*yamlv3.Node{toDocument}},
+				compareOptions...,
+			)
+
+			if err != nil {
+				return Report{}, fmt.Errorf("failed to compare %s: %w", name, err)
+			}
+
+			for _, diff := range report.Diffs {
+				diff.Path.Root = &result.From
+				diff.Path.DocumentIdx = idx
+				result.Diffs = append(result.Diffs, diff)
+			}
+		}
+	}
+
+	return result, nil
+}
+
+// loadKubectlDiffDirectory loads all files of the directory by file name, with
+// the server managed fields removed from the resources
+func loadKubectlDiffDirectory(dir string) (map[string]*yamlv3.Node, error) {
+	paths, err := listFiles(dir, DirectoryFilter{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read files in directory %s: %w", dir, err)
+	}
+
+	result := make(map[string]*yamlv3.Node, len(paths))
+	for path := range paths {
+		inputFile, err := ytbx.LoadFile(filepath.Join(dir, path))
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", path, err)
+		}
+
+		if len(inputFile.Documents) != 1 {
+			return nil, fmt.Errorf("failed to load %s, because it contains %d documents instead of one", path, len(inputFile.Documents))
+		}
+
+		StripServerManagedFields(inputFile.Documents[0])
+		result[path] = inputFile.Documents[0]
+	}
+
+	return result, nil
+}
+
+func documentContent(document *yamlv3.Node) *yamlv3.Node {
+	if document != nil && document.Kind == yamlv3.DocumentNode && len(document.Content) > 0 {
+		return document.Content[0]
+	}
+
+	return document
+}
@@ -0,0 +1,338 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// managedFieldsEntry is the relevant subset of a Kubernetes managed fields
+// entry, which describes the fields owned by one field manager
+type managedFieldsEntry struct {
+	Manager   string
+	Operation string
+	FieldsV1  *yamlv3.Node
+}
+
+// FieldManagers returns the sorted names of all field managers that used
+// server-side apply for the provided Kubernetes resource document
+func FieldManagers(document *yamlv3.Node) []string {
+	var result []string
+	for _, entry := range managedFieldsEntries(document) {
+		if entry.Operation == "Apply" && !containsString(result, entry.Manager) {
+			result = append(result, entry.Manager)
+		}
+	}
+
+	sort.Strings(result)
+	return result
+}
+
+// AppliedConfiguration reconstructs the configuration that was applied using
+// server-side apply based on the fields owned by the given field manager in
+// the managed fields of the resource. The values are taken from the resource
+// itself. In case no manager name is provided, the only field manager that
+// used server-side apply is used.
+func AppliedConfiguration(document *yamlv3.Node, manager string) (*yamlv3.Node, error) {
+	if manager == "" {
+		managers := FieldManagers(document)
+		switch len(managers) {
+		case 0:
+			return nil, fmt.Errorf("no managed fields of a server-side apply found")
+
+		case 1:
+			manager = managers[0]
+
+		default:
+			return nil, fmt.Errorf("more than one field manager used server-side apply, select one of: %s", strings.Join(managers, ", "))
+		}
+	}
+
+	var fieldSet *yamlv3.Node
+	var available []string
+	for _, entry := range managedFieldsEntries(document) {
+		if entry.Manager == manager && entry.FieldsV1 != nil {
+			fieldSet = mergeFieldSets(fieldSet, entry.FieldsV1)
+		}
+
+		if !containsString(available, entry.Manager) {
+			available = append(available, entry.Manager)
+		}
+	}
+
+	if fieldSet == nil {
+		sort.Strings(available)
+		return nil, fmt.Errorf("no managed fields of field manager %s found, available field managers are: %s", manager, strings.Join(available, ", "))
+	}
+
+	live := documentContent(document)
+	result := extractFields(fieldSet, live)
+
+	// The identity of the resource is not part of the managed fields, but is
+	// always part of the applied configuration
+	for _, path := range [][]string{{"metadata", "namespace"}, {"metadata", "name"}, {"kind"}, {"apiVersion"}} {
+		if value, ok := lookupMappingEntry(live, path...); ok {
+			setMappingEntry(result, value, path...)
+		}
+	}
+
+	return &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{result}}, nil
+}
+
+func managedFieldsEntries(document *yamlv3.Node) []managedFieldsEntry {
+	managedFields, ok := lookupMappingEntry(documentContent(document), "metadata", "managedFields")
+	if !ok || managedFields.Kind != yamlv3.SequenceNode {
+		return nil
+	}
+
+	var result []managedFieldsEntry
+	for _, item := range managedFields.Content {
+		if item.Kind != yamlv3.MappingNode {
+			continue
+		}
+
+		var entry managedFieldsEntry
+		if manager, ok := findValueByKey(item, "manager"); ok {
+			entry.Manager = manager.Value
+		}
+
+		if operation, ok := findValueByKey(item, "operation"); ok {
+			entry.Operation = operation.Value
+		}
+
+		if fieldsV1, ok := findValueByKey(item, "fieldsV1"); ok && fieldsV1.Kind == yamlv3.MappingNode {
+			entry.FieldsV1 = fieldsV1
+		}
+
+		result = append(result, entry)
+	}
+
+	return result
+}
+
+// mergeFieldSets returns the union of two field sets in fieldsV1 format
+func mergeFieldSets(a *yamlv3.Node, b *yamlv3.Node) *yamlv3.Node {
+	if a == nil {
+		return b
+	}
+
+	result := &yamlv3.Node{Kind: yamlv3.MappingNode, Content: append([]*yamlv3.Node{}, a.Content...)}
+	for i := 0; i+1 < len(b.Content); i += 2 {
+		key, value := b.Content[i], b.Content[i+1]
+
+		existing, ok := findValueByKey(result, key.Value)
+		if !ok {
+			result.Content = append(result.Content, key, value)
+			continue
+		}
+
+		merged := mergeFieldSets(existing, value)
+		for j := 0; j+1 < len(result.Content); j += 2 {
+			if result.Content[j].Value == key.Value {
+				result.Content[j+1] = merged
+			}
+		}
+	}
+
+	return result
+}
+
+// extractFields returns a copy of the live node that only contains the fields
+// listed in the field set, which uses the fieldsV1 format: `f:<name>` for map
+// entries, `k:<json>` for list entries identified by keys, `v:<json>` for list
+// entries identified by value, `i:<index>` for list entries by position, and
+// `.` for the node itself.
+func extractFields(fieldSet *yamlv3.Node, live *yamlv3.Node) *yamlv3.Node {
+	live = followAlias(live)
+
+	// A field set without nested fields means that the whole value is owned
+	if !hasNestedFields(fieldSet) {
+		return copyNode(live)
+	}
+
+	switch live.Kind {
+	case yamlv3.MappingNode:
+		result := &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: live.Tag}
+		for i := 0; i+1 < len(live.Content); i += 2 {
+			key, value := followAlias(live.Content[i]), live.Content[i+1]
+			if fields, ok := findValueByKey(fieldSet, "f:"+key.Value); ok {
+				result.Content = append(result.Content, copyNode(key), extractFields(fields, value))
+			}
+		}
+
+		return result
+
+	case yamlv3.SequenceNode:
+		result := &yamlv3.Node{Kind: yamlv3.SequenceNode, Tag: live.Tag}
+		for idx, item := range live.Content {
+			if fields, ok := listItemFields(fieldSet, idx, followAlias(item)); ok {
+				result.Content = append(result.Content, extractFields(fields, item))
+			}
+		}
+
+		return result
+	}
+
+	return copyNode(live)
+}
+
+func hasNestedFields(fieldSet *yamlv3.Node) bool {
+	if fieldSet == nil || fieldSet.Kind != yamlv3.MappingNode {
+		return false
+	}
+
+	for i := 0; i < len(fieldSet.Content); i += 2 {
+		if fieldSet.Content[i].Value != "." {
+			return true
+		}
+	}
+
+	return false
+}
+
+// listItemFields returns the field set for the list entry, which is either
+// referenced by position, by its value, or by a set of identifying keys
+func listItemFields(fieldSet *yamlv3.Node, idx int, item *yamlv3.Node) (*yamlv3.Node, bool) {
+	for i := 0; i+1 < len(fieldSet.Content); i += 2 {
+		key, fields := fieldSet.Content[i].Value, fieldSet.Content[i+1]
+
+		switch {
+		case strings.HasPrefix(key, "i:"):
+			if position, err := strconv.Atoi(strings.TrimPrefix(key, "i:")); err == nil && position == idx {
+				return fields, true
+			}
+
+		case strings.HasPrefix(key, "v:"):
+			var value interface{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(key, "v:")), &value); err == nil && equalsJSON(value, item) {
+				return fields, true
+			}
+
+		case strings.HasPrefix(key, "k:"):
+			var keys map[string]interface{}
+			if err := json.Unmarshal([]byte(strings.TrimPrefix(key, "k:")), &keys); err != nil || item.Kind != yamlv3.MappingNode {
+				continue
+			}
+
+			matches := true
+			for name, value := range keys {
+				if itemValue, ok := findValueByKey(item, name); !ok || !equalsJSON(value, itemValue) {
+					matches = false
+					break
+				}
+			}
+
+			if matches {
+				return fields, true
+			}
+		}
+	}
+
+	return nil, false
+}
+
+// equalsJSON returns whether the JSON value and the node have the same JSON
+// representation, which is independent of the number type in use
+func equalsJSON(value interface{}, node *yamlv3.Node) bool {
+	var nodeValue interface{}
+	if err := node.Decode(&nodeValue); err != nil {
+		return false
+	}
+
+	a, errA := json.Marshal(value)
+	b, errB := json.Marshal(nodeValue)
+	return errA == nil && errB == nil && string(a) == string(b)
+}
+
+func lookupMappingEntry(node *yamlv3.Node, keys ...string) (*yamlv3.Node, bool) {
+	for _, key := range keys {
+		if node == nil || node.Kind != yamlv3.MappingNode {
+			return nil, false
+		}
+
+		value, ok := findValueByKey(node, key)
+		if !ok {
+			return nil, false
+		}
+
+		node = value
+	}
+
+	return node, true
+}
+
+// setMappingEntry sets the value in nested mapping nodes, where missing
+// mapping nodes are created and new entries are added to the start
+func setMappingEntry(node *yamlv3.Node, value *yamlv3.Node, keys ...string) {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value != keys[0] {
+			continue
+		}
+
+		if len(keys) == 1 {
+			node.Content[i+1] = copyNode(value)
+		} else if next := followAlias(node.Content[i+1]); next.Kind == yamlv3.MappingNode {
+			setMappingEntry(next, value, keys[1:]...)
+		}
+
+		return
+	}
+
+	entry := copyNode(value)
+	if len(keys) > 1 {
+		entry = &yamlv3.Node{Kind: yamlv3.MappingNode, Tag: "!!map"}
+		setMappingEntry(entry, value, keys[1:]...)
+	}
+
+	node.Content = append([]*yamlv3.Node{{Kind: yamlv3.ScalarNode, Tag: "!!str", Value: keys[0]}, entry}, node.Content...)
+}
+
+func copyNode(node *yamlv3.Node) *yamlv3.Node {
+	if node == nil {
+		return nil
+	}
+
+	result := *node
+	if node.Content != nil {
+		result.Content = make([]*yamlv3.Node, len(node.Content))
+		for i, entry := range node.Content {
+			result.Content[i] = copyNode(entry)
+		}
+	}
+
+	return &result
+}
+
+func containsString(list []string, value string) bool {
+	for _, entry := range list {
+		if entry == value {
+			return true
+		}
+	}
+
+	return false
+}
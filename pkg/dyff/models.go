@@ -46,9 +46,16 @@ type Detail struct {
 }
 
 // Diff encapsulates everything noteworthy about a difference
+//
+// Since the Severity field was added, code that creates a Diff using an
+// unkeyed composite literal no longer compiles and needs to use field names,
+// for example Diff{Path: path, Details: details}.
 type Diff struct {
 	Path    ytbx.Path
 	Details []Detail
+
+	// Severity is set in case the report was classified using a policy
+	Severity Severity
 }
 
 // Report encapsulates the actual end-result of the comparison: The input data
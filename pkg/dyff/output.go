@@ -21,37 +21,15 @@
 package dyff
 
 import (
-	"github.com/gonvenience/bunt"
 	"github.com/gonvenience/neat"
-	"github.com/lucasb-eyer/go-colorful"
 )
 
 func yamlStringInRedishColors(input interface{}) (string, error) {
-	return neat.NewOutputProcessor(true, true, &map[string]colorful.Color{
-		"keyColor":           bunt.FireBrick,
-		"indentLineColor":    {R: 0.2, G: 0, B: 0},
-		"scalarDefaultColor": bunt.LightCoral,
-		"boolColor":          bunt.LightCoral,
-		"floatColor":         bunt.LightCoral,
-		"intColor":           bunt.LightCoral,
-		"multiLineTextColor": bunt.DarkSalmon,
-		"nullColor":          bunt.Salmon,
-		"emptyStructures":    bunt.LightSalmon,
-		"dashColor":          bunt.FireBrick,
-	}).ToYAML(input)
+	output, err := neat.NewOutputProcessor(true, true, &currentTheme.RemovalSchema).ToYAML(input)
+	return ASCIISafe(output), err
 }
 
 func yamlStringInGreenishColors(input interface{}) (string, error) {
-	return neat.NewOutputProcessor(true, true, &map[string]colorful.Color{
-		"keyColor":           bunt.Green,
-		"indentLineColor":    {R: 0, G: 0.2, B: 0},
-		"scalarDefaultColor": bunt.LimeGreen,
-		"boolColor":          bunt.LimeGreen,
-		"floatColor":         bunt.LimeGreen,
-		"intColor":           bunt.LimeGreen,
-		"multiLineTextColor": bunt.OliveDrab,
-		"nullColor":          bunt.Olive,
-		"emptyStructures":    bunt.DarkOliveGreen,
-		"dashColor":          bunt.Green,
-	}).ToYAML(input)
+	output, err := neat.NewOutputProcessor(true, true, &currentTheme.AdditionSchema).ToYAML(input)
+	return ASCIISafe(output), err
 }
@@ -29,6 +29,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io"
+	"strconv"
 	"strings"
 	"unicode/utf8"
 
@@ -56,6 +57,18 @@ type HumanReport struct {
 	UseGoPatchPaths      bool
 	MinorChangeThreshold float64
 
+	// MaxValueLines and MaxValueBytes limit the size of each rendered value,
+	// where the middle section of larger values is elided (zero for no limit)
+	MaxValueLines int
+	MaxValueBytes int
+
+	// ContextLines defines the number of unchanged lines that are shown around
+	// changed lines of multi-line strings, zero shows both values in full
+	ContextLines int
+
+	// ShowStats adds a statistics section at the end of the report
+	ShowStats bool
+
 	Report
 }
 
@@ -85,13 +98,13 @@ func (report *HumanReport) WriteReport(out io.Writer) error {
 			bunt.ForegroundFunc(func(x int, _ int, _ rune) *colorful.Color {
 				switch {
 				case x < 7:
-					return &colorful.Color{R: .45, G: .71, B: .30}
+					return &currentTheme.BannerAddition
 
 				case x < 13:
-					return &colorful.Color{R: .79, G: .76, B: .38}
+					return &currentTheme.BannerModification
 
 				case x < 21:
-					return &colorful.Color{R: .65, G: .17, B: .17}
+					return &currentTheme.BannerRemoval
 				}
 
 				return nil
@@ -99,13 +112,20 @@ func (report *HumanReport) WriteReport(out io.Writer) error {
 		))
 	}
 
-	// Loop over the diff and generate each report into the buffer
-	for _, diff := range report.Diffs {
+	// Loop over the diff and generate each report into the buffer, the most
+	// severe differences first in case the report was classified by a policy
+	for _, diff := range sortBySeverity(report.Diffs) {
 		if err := report.generateHumanDiffOutput(writer, diff, report.UseGoPatchPaths, showPathRoot); err != nil {
 			return err
 		}
 	}
 
+	if report.ShowStats {
+		if err := writeStats(writer, report.Stats()); err != nil {
+			return err
+		}
+	}
+
 	// Finish with one last newline so that we do not end next to the prompt
 	writer.WriteString("\n")
 	return nil
@@ -115,6 +135,9 @@ func (report *HumanReport) WriteReport(out io.Writer) error {
 func (report *HumanReport) generateHumanDiffOutput(output stringWriter, diff Diff, useGoPatchPaths bool, showPathRoot bool) error {
 	output.WriteString("\n")
 	output.WriteString(pathToString(diff.Path, useGoPatchPaths, showPathRoot))
+	if diff.Severity != SeverityNone {
+		output.WriteString("  " + colored(severityColor(diff.Severity), "[%s]", diff.Severity))
+	}
 	output.WriteString("\n")
 
 	blocks := make([]string, len(diff.Details))
@@ -162,14 +185,14 @@ func (report *HumanReport) generateHumanDetailOutputAddition(detail Detail) (str
 
 	switch detail.To.Kind {
 	case yamlv3.SequenceNode:
-		output.WriteString(yellow("%c %s added:\n",
-			ADDITION,
+		output.WriteString(yellow("%s %s added:\n",
+			indicator(ADDITION),
 			text.Plural(len(detail.To.Content), "list entry", "list entries"),
 		))
 
 	case yamlv3.MappingNode:
-		output.WriteString(yellow("%c %s added:\n",
-			ADDITION,
+		output.WriteString(yellow("%s %s added:\n",
+			indicator(ADDITION),
 			text.Plural(len(detail.To.Content)/2, "map entry", "map entries"),
 		))
 	}
@@ -180,7 +203,7 @@ func (report *HumanReport) generateHumanDetailOutputAddition(detail Detail) (str
 		return "", err
 	}
 
-	report.writeTextBlocks(&output, 2, yamlOutput)
+	report.writeTextBlocks(&output, 2, report.truncate(yamlOutput))
 
 	return output.String(), nil
 }
@@ -191,11 +214,11 @@ func (report *HumanReport) generateHumanDetailOutputRemoval(detail Detail) (stri
 	switch detail.From.Kind {
 	case yamlv3.SequenceNode:
 		text := text.Plural(len(detail.From.Content), "list entry", "list entries")
-		output.WriteString(yellow("%c %s removed:\n", REMOVAL, text))
+		output.WriteString(yellow("%s %s removed:\n", indicator(REMOVAL), text))
 
 	case yamlv3.MappingNode:
 		text := text.Plural(len(detail.From.Content)/2, "map entry", "map entries")
-		output.WriteString(yellow("%c %s removed:\n", REMOVAL, text))
+		output.WriteString(yellow("%s %s removed:\n", indicator(REMOVAL), text))
 	}
 
 	ytbx.RestructureObject(detail.From)
@@ -204,7 +227,7 @@ func (report *HumanReport) generateHumanDetailOutputRemoval(detail Detail) (stri
 		return "", err
 	}
 
-	report.writeTextBlocks(&output, 2, yamlOutput)
+	report.writeTextBlocks(&output, 2, report.truncate(yamlOutput))
 
 	return output.String(), nil
 }
@@ -234,23 +257,23 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 			return "", err
 		}
 
-		output.WriteString(yellow("%c content change\n", MODIFICATION))
+		output.WriteString(yellow("%s content change\n", indicator(MODIFICATION)))
 		report.writeTextBlocks(&output, 0,
-			red("%s", createStringWithPrefix("  - ", hex.Dump(from))),
-			green("%s", createStringWithPrefix("  + ", hex.Dump(to))),
+			red("%s", createStringWithPrefix("  - ", report.truncate(hex.Dump(from)))),
+			green("%s", createStringWithPrefix("  + ", report.truncate(hex.Dump(to)))),
 		)
 
 	default:
 		if fromType != toType {
-			output.WriteString(yellow("%c type change from %s to %s\n",
-				MODIFICATION,
+			output.WriteString(yellow("%s type change from %s to %s\n",
+				indicator(MODIFICATION),
 				italic(fromType),
 				italic(toType),
 			))
 
 		} else {
-			output.WriteString(yellow("%c value change\n",
-				MODIFICATION,
+			output.WriteString(yellow("%s value change\n",
+				indicator(MODIFICATION),
 			))
 		}
 
@@ -264,8 +287,8 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 			return "", err
 		}
 
-		output.WriteString(red("%s", createStringWithPrefix("  - ", strings.TrimRight(from, "\n"))))
-		output.WriteString(green("%s", createStringWithPrefix("  + ", strings.TrimRight(to, "\n"))))
+		output.WriteString(red("%s", createStringWithPrefix("  - ", report.truncate(strings.TrimRight(from, "\n")))))
+		output.WriteString(green("%s", createStringWithPrefix("  + ", report.truncate(strings.TrimRight(to, "\n")))))
 	}
 
 	return output.String(), nil
@@ -274,7 +297,7 @@ func (report *HumanReport) generateHumanDetailOutputModification(detail Detail)
 func (report *HumanReport) generateHumanDetailOutputOrderchange(detail Detail) (string, error) {
 	var output bytes.Buffer
 
-	output.WriteString(yellow("%c order changed\n", ORDERCHANGE))
+	output.WriteString(yellow("%s order changed\n", indicator(ORDERCHANGE)))
 	switch detail.From.Kind {
 	case yamlv3.SequenceNode:
 		asStringList := func(sequenceNode *yamlv3.Node) ([]string, error) {
@@ -313,8 +336,8 @@ func (report *HumanReport) generateHumanDetailOutputOrderchange(detail Detail) (
 
 		} else {
 			output.WriteString(CreateTableStyleString(" ", 2,
-				red("%s", strings.Join(from, "\n")),
-				green("%s", strings.Join(to, "\n"))))
+				red("%s", report.truncate(strings.Join(from, "\n"))),
+				green("%s", report.truncate(strings.Join(to, "\n")))))
 		}
 	}
 
@@ -323,35 +346,44 @@ func (report *HumanReport) generateHumanDetailOutputOrderchange(detail Detail) (
 
 func (report *HumanReport) writeStringDiff(output stringWriter, from string, to string) {
 	if fromCertText, toCertText, err := report.LoadX509Certs(from, to); err == nil {
-		output.WriteString(yellow("%c certificate change\n", MODIFICATION))
+		output.WriteString(yellow("%s certificate change\n", indicator(MODIFICATION)))
 		output.WriteString(report.highlightByLine(fromCertText, toCertText))
 
 	} else if isWhitespaceOnlyChange(from, to) {
-		output.WriteString(yellow("%c whitespace only change\n", MODIFICATION))
+		output.WriteString(yellow("%s whitespace only change\n", indicator(MODIFICATION)))
 		report.writeTextBlocks(output, 0,
-			red("%s", createStringWithPrefix("  - ", showWhitespaceCharacters(from))),
-			green("%s", createStringWithPrefix("  + ", showWhitespaceCharacters(to))),
+			red("%s", createStringWithPrefix("  - ", report.truncate(showWhitespaceCharacters(from)))),
+			green("%s", createStringWithPrefix("  + ", report.truncate(showWhitespaceCharacters(to)))),
 		)
 	} else if isMultiLine(from, to) {
-		output.WriteString(yellow("%c value change\n", MODIFICATION))
-		report.writeTextBlocks(output, 0,
-			red("%s", createStringWithPrefix("  - ", from)),
-			green("%s", createStringWithPrefix("  + ", to)),
-		)
-	} else if isMinorChange(from, to, report.MinorChangeThreshold) {
-		output.WriteString(yellow("%c value change\n", MODIFICATION))
+		output.WriteString(yellow("%s value change\n", indicator(MODIFICATION)))
+		if report.ContextLines > 0 {
+			output.WriteString(report.contextDiff(from, to))
+
+		} else {
+			report.writeTextBlocks(output, 0,
+				red("%s", createStringWithPrefix("  - ", report.truncate(from))),
+				green("%s", createStringWithPrefix("  + ", report.truncate(to))),
+			)
+		}
+	} else if !report.exceedsValueBytes(from, to) && isMinorChange(from, to, report.MinorChangeThreshold) {
+		output.WriteString(yellow("%s value change\n", indicator(MODIFICATION)))
 		diffs := diffmatchpatch.New().DiffMain(from, to, false)
 		output.WriteString(highlightRemovals(diffs))
 		output.WriteString(highlightAdditions(diffs))
 
 	} else {
-		output.WriteString(yellow("%c value change\n", MODIFICATION))
-		output.WriteString(red("%s", createStringWithPrefix("  - ", from)))
-		output.WriteString(green("%s", createStringWithPrefix("  + ", to)))
+		output.WriteString(yellow("%s value change\n", indicator(MODIFICATION)))
+		output.WriteString(red("%s", createStringWithPrefix("  - ", report.truncate(from))))
+		output.WriteString(green("%s", createStringWithPrefix("  + ", report.truncate(to))))
 	}
 }
 
 func (report *HumanReport) highlightByLine(from, to string) string {
+	if report.ContextLines > 0 {
+		return report.contextDiff(from, to)
+	}
+
 	fromLines := strings.Split(from, "\n")
 	toLines := strings.Split(to, "\n")
 
@@ -370,19 +402,112 @@ func (report *HumanReport) highlightByLine(from, to string) string {
 		}
 
 		report.writeTextBlocks(&buf, 0,
-			createStringWithPrefix(red("  - "), strings.Join(fromLines, "\n")),
-			createStringWithPrefix(green("  + "), strings.Join(toLines, "\n")))
+			createStringWithPrefix(red("  - "), report.truncate(strings.Join(fromLines, "\n"))),
+			createStringWithPrefix(green("  + "), report.truncate(strings.Join(toLines, "\n"))))
 
 	} else {
 		report.writeTextBlocks(&buf, 0,
-			red("%s", createStringWithPrefix("  - ", from)),
-			green("%s", createStringWithPrefix("  + ", to)),
+			red("%s", createStringWithPrefix("  - ", report.truncate(from))),
+			green("%s", createStringWithPrefix("  + ", report.truncate(to))),
 		)
 	}
 
 	return buf.String()
 }
 
+// contextDiff creates a line based difference of the two provided strings,
+// which only shows the changed lines and the configured number of unchanged
+// lines around them, comparable to the hunks of a unified diff
+func (report *HumanReport) contextDiff(from, to string) string {
+	type line struct {
+		operation diffmatchpatch.Operation
+		text      string
+	}
+
+	// Encode each distinct line as one rune so that the difference can be
+	// calculated on a line basis (skipping the UTF-16 surrogate range, since
+	// these are not valid runes in strings)
+	var lineArray []string
+	lineLookup := map[string]rune{}
+	encode := func(text string) []rune {
+		var result []rune
+		for _, line := range strings.Split(text, "\n") {
+			r, ok := lineLookup[line]
+			if !ok {
+				r = rune(len(lineArray))
+				if r >= 0xD800 {
+					r += 0x800
+				}
+
+				lineArray = append(lineArray, line)
+				lineLookup[line] = r
+			}
+
+			result = append(result, r)
+		}
+
+		return result
+	}
+
+	decode := func(r rune) string {
+		if r >= 0xD800 {
+			r -= 0x800
+		}
+
+		return lineArray[r]
+	}
+
+	fromRunes, toRunes := encode(from), encode(to)
+
+	var lines []line
+	for _, diff := range diffmatchpatch.New().DiffMainRunes(fromRunes, toRunes, false) {
+		for _, r := range diff.Text {
+			lines = append(lines, line{diff.Type, decode(r)})
+		}
+	}
+
+	show := make([]bool, len(lines))
+	for i := range lines {
+		if lines[i].operation != diffmatchpatch.DiffEqual {
+			for j := max(0, i-report.ContextLines); j <= min(len(lines)-1, i+report.ContextLines); j++ {
+				show[j] = true
+			}
+		}
+	}
+
+	var output []string
+	for i := 0; i < len(lines); i++ {
+		if !show[i] {
+			skipped := 0
+			for ; i < len(lines) && !show[i]; i++ {
+				skipped++
+			}
+
+			output = append(output, fmt.Sprintf("    %s", elision(skipped, "unchanged line")))
+			i--
+			continue
+		}
+
+		switch lines[i].operation {
+		case diffmatchpatch.DiffDelete:
+			output = append(output, red("  - %s", report.truncateLine(lines[i].text)))
+
+		case diffmatchpatch.DiffInsert:
+			output = append(output, green("  + %s", report.truncateLine(lines[i].text)))
+
+		default:
+			output = append(output, fmt.Sprintf("    %s", report.truncateLine(lines[i].text)))
+		}
+	}
+
+	var buf bytes.Buffer
+	for _, line := range report.limitLines(output, "    ") {
+		buf.WriteString(line + "\n")
+	}
+
+	return buf.String()
+}
+
 func humanReadableType(node *yamlv3.Node) string {
 	switch node.Kind {
 	case yamlv3.DocumentNode:
@@ -563,7 +688,7 @@ func isWhitespaceOnlyChange(from string, to string) bool {
 }
 
 func showWhitespaceCharacters(text string) string {
-	return strings.Replace(strings.Replace(text, "\n", bold("↵\n"), -1), " ", bold("·"), -1)
+	return strings.Replace(strings.Replace(text, "\n", bold(Symbol("↵")+"\n"), -1), " ", bold(Symbol("·")), -1)
 }
 
 func createStringWithPrefix(prefix string, obj interface{}) string {
@@ -596,6 +721,131 @@ func stringArrayLen(list []string) int {
 	return result
 }
 
+// truncate limits the provided (multi-line) text to the configured maximum
+// number of lines and bytes by eliding the middle section of the text
+func (report *HumanReport) truncate(text string) string {
+	if report.MaxValueLines <= 0 && report.MaxValueBytes <= 0 {
+		return text
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := range lines {
+		lines[i] = report.truncateLine(lines[i])
+	}
+
+	return strings.Join(report.limitLines(lines, ""), "\n")
+}
+
+// limitLines limits the provided lines to the configured maximum number of
+// lines and bytes by replacing the middle section with an indented marker
+func (report *HumanReport) limitLines(lines []string, indent string) []string {
+	if report.MaxValueLines <= 0 && report.MaxValueBytes <= 0 {
+		return lines
+	}
+
+	maxLines := len(lines)
+	if report.MaxValueLines > 0 && report.MaxValueLines < maxLines {
+		maxLines = report.MaxValueLines
+	}
+
+	// Alternately take lines from the start and the end of the text until
+	// either the maximum number of lines, or the maximum size is reached
+	var head, tail, size int
+	for head+tail < maxLines {
+		next := lines[head]
+		if tail < head {
+			next = lines[len(lines)-1-tail]
+		}
+
+		length := len(bunt.RemoveAllEscapeSequences(next))
+		if report.MaxValueBytes > 0 && head+tail > 0 && size+length > report.MaxValueBytes {
+			break
+		}
+
+		size += length
+		if tail < head {
+			tail++
+		} else {
+			head++
+		}
+	}
+
+	if head+tail == len(lines) {
+		return lines
+	}
+
+	result := make([]string, 0, head+tail+1)
+	result = append(result, lines[:head]...)
+	result = append(result, indent+elision(len(lines)-head-tail, "more line"))
+	result = append(result, lines[len(lines)-tail:]...)
+
+	return result
+}
+
+// truncateLine cuts off a single line that is longer than the configured
+// maximum number of bytes
+func (report *HumanReport) truncateLine(line string) string {
+	if report.MaxValueBytes <= 0 {
+		return line
+	}
+
+	plain := bunt.RemoveAllEscapeSequences(line)
+	if len(plain) <= report.MaxValueBytes {
+		return line
+	}
+
+	// Make sure not to cut in the middle of a multi-byte rune
+	cut := report.MaxValueBytes
+	for cut > 0 && !utf8.RuneStart(plain[cut]) {
+		cut--
+	}
+
+	return bunt.Substring(line, 0, utf8.RuneCountInString(plain[:cut])) +
+		" " + elision(len(plain)-cut, "more byte")
+}
+
+func (report *HumanReport) exceedsValueBytes(values ...string) bool {
+	if report.MaxValueBytes <= 0 {
+		return false
+	}
+
+	for _, value := range values {
+		if len(value) > report.MaxValueBytes {
+			return true
+		}
+	}
+
+	return false
+}
+
+// elision creates the note that is shown in place of omitted content
+func elision(amount int, unit string) string {
+	if amount != 1 {
+		unit += "s"
+	}
+
+	return italic("%s %s %s", Symbol("…"), formatNumber(amount), unit)
+}
+
+// formatNumber renders an integer with thousands separators, e.g. 4,812
+func formatNumber(number int) string {
+	if number < 0 {
+		return "-" + formatNumber(-number)
+	}
+
+	digits := strconv.Itoa(number)
+	var buf bytes.Buffer
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			buf.WriteRune(',')
+		}
+
+		buf.WriteRune(digit)
+	}
+
+	return buf.String()
+}
+
 // writeTextBlocks writes strings into the provided buffer in either a table style (each string a column) or list style (each string a row)
 func (report *HumanReport) writeTextBlocks(buf stringWriter, indent int, blocks ...string) {
 	const separator = "   "
@@ -21,7 +21,9 @@
 package dyff_test
 
 import (
+	"bytes"
 	"fmt"
+	"strings"
 
 	. "github.com/gonvenience/bunt"
 	. "github.com/onsi/ginkgo"
@@ -29,6 +31,7 @@ import (
 
 	. "github.com/homeport/dyff/pkg/dyff"
 
+	"github.com/gonvenience/neat"
 	"github.com/gonvenience/ytbx"
 )
 
@@ -181,6 +184,158 @@ variables.ROUTER_TLS_PEM.options
 		})
 	})
 
+	Context("limiting the size of values", func() {
+		var numberedLines = func(from, to int) string {
+			var lines []string
+			for i := from; i <= to; i++ {
+				lines = append(lines, fmt.Sprintf("line %d", i))
+			}
+
+			return strings.Join(lines, "\n")
+		}
+
+		var render = func(report HumanReport, diff Diff) string {
+			report.Report = Report{Diffs: []Diff{diff}}
+			report.OmitHeader = true
+
+			var buf bytes.Buffer
+			Expect(report.WriteReport(&buf)).To(Succeed())
+			return buf.String()
+		}
+
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+		})
+
+		It("should elide the middle section of values with too many lines", func() {
+			diff := singleDiff("/text", MODIFICATION, numberedLines(1, 5000), numberedLines(2, 5001))
+			Expect(render(HumanReport{MaxValueLines: 4}, diff)).To(BeEquivalentTo(`
+text
+  ± value change
+    - line 1                 + line 2
+      line 2                   line 3
+      … 4,996 more lines       … 4,996 more lines
+      line 4999                line 5000
+      line 5000                line 5001
+
+`))
+		})
+
+		It("should cut off values that are larger than the byte limit", func() {
+			diff := singleDiff("/blob", MODIFICATION, strings.Repeat("a", 5000), strings.Repeat("b", 5000))
+			Expect(render(HumanReport{MaxValueBytes: 10}, diff)).To(BeEquivalentTo(`
+blob
+  ± value change
+    - aaaaaaaaaa … 4,990 more bytes
+    + bbbbbbbbbb … 4,990 more bytes
+
+`))
+		})
+
+		It("should only show the changed lines with context for multi-line strings", func() {
+			from := numberedLines(1, 20)
+			to := strings.Replace(from, "line 10\n", "line ten\n", 1)
+
+			diff := singleDiff("/text", MODIFICATION, from, to)
+			Expect(render(HumanReport{ContextLines: 2}, diff)).To(BeEquivalentTo(`
+text
+  ± value change
+      … 7 unchanged lines
+      line 8
+      line 9
+    - line 10
+    + line ten
+      line 11
+      line 12
+      … 8 unchanged lines
+
+`))
+		})
+
+		It("should apply the line limit to the changed lines with context", func() {
+			from := numberedLines(1, 20)
+			to := strings.Replace(strings.Replace(from, "line 5\n", "line five\n", 1), "line 15\n", "line fifteen\n", 1)
+
+			diff := singleDiff("/text", MODIFICATION, from, to)
+			Expect(render(HumanReport{ContextLines: 1, MaxValueLines: 4}, diff)).To(BeEquivalentTo(`
+text
+  ± value change
+      … 3 unchanged lines
+      line 4
+      … 7 more lines
+      line 16
+      … 4 unchanged lines
+
+`))
+		})
+	})
+
+	Context("ASCII-only output", func() {
+		BeforeEach(func() {
+			SetColorSettings(OFF, OFF)
+			Expect(ASCIISetting.Set("on")).To(Succeed())
+		})
+
+		AfterEach(func() {
+			SetColorSettings(AUTO, AUTO)
+			Expect(ASCIISetting.Set("auto")).To(Succeed())
+		})
+
+		It("should use ASCII change indicators", func() {
+			Expect(humanDiff(singleDiff("/some/yaml/structure/string", MODIFICATION, "fOObar?", "Foobar!"))).To(BeEquivalentTo(`
+some.yaml.structure.string
+  ~ value change
+    - fOObar?
+    + Foobar!
+
+`))
+
+			Expect(humanDiff(singleDiff("/some/list", ORDERCHANGE, []string{"a", "b"}, []string{"b", "a"}))).To(BeEquivalentTo(`
+some.list
+  <> order changed
+    - a, b
+    + b, a
+
+`))
+		})
+
+		It("should use ASCII whitespace markers", func() {
+			Expect(humanDiff(singleDiff("/input", MODIFICATION, "foo bar", "foo bar\n"))).To(BeEquivalentTo("\ninput\n  ~ whitespace only change\n    - foo.bar     + foo.bar$\n\n\n"))
+		})
+
+		It("should not change non-ASCII characters of the values", func() {
+			Expect(humanDiff(singleDiff("/input", MODIFICATION, "a ± b", "a → │ b"))).To(BeEquivalentTo(`
+input
+  ~ value change
+    - a ± b
+    + a → │ b
+
+`))
+		})
+
+		It("should only replace the indent helper lines of the neat output", func() {
+			SetColorSettings(ON, ON)
+
+			output, err := neat.NewOutputProcessor(true, true, nil).ToYAML(yml(`{"a": {"b": {"c": "│ ± …"}}}`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(output).To(ContainSubstring("│"))
+
+			plain := RemoveAllEscapeSequences(ASCIISafe(output))
+			Expect(plain).To(ContainSubstring(`| | c: "│ ± …"`))
+			Expect(strings.Count(plain, "│")).To(Equal(1))
+		})
+
+		It("should keep the change kind constants unchanged", func() {
+			report := Report{Diffs: []Diff{singleDiff("/some/yaml/structure/string", MODIFICATION, "foo", "bar")}}
+			Expect(report.Diffs[0].Details[0].Kind).To(Equal(MODIFICATION))
+			Expect(MODIFICATION).To(Equal('±'))
+		})
+	})
+
 	Context("reported output issues", func() {
 		BeforeEach(func() {
 			SetColorSettings(OFF, OFF)
@@ -0,0 +1,368 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// JSONReport is a reporter that writes the report in a machine readable JSON
+// format, which can be loaded again using LoadReport
+type JSONReport struct {
+	Report
+
+	// ShowStats adds the statistics of the report to the JSON output
+	ShowStats bool
+}
+
+type jsonReport struct {
+	From  jsonInputFile `json:"from"`
+	To    jsonInputFile `json:"to"`
+	Diffs []jsonDiff    `json:"diffs"`
+	Stats *jsonStats    `json:"stats,omitempty"`
+}
+
+type jsonStats struct {
+	Differences   int            `json:"differences"`
+	ByKind        map[string]int `json:"byKind"`
+	ByTopLevelKey map[string]int `json:"byTopLevelKey"`
+	ByResource    map[string]int `json:"byResource,omitempty"`
+}
+
+type jsonInputFile struct {
+	Location  string   `json:"location"`
+	Note      string   `json:"note,omitempty"`
+	Documents int      `json:"documents"`
+	Names     []string `json:"names,omitempty"`
+}
+
+type jsonDiff struct {
+	Path         string            `json:"path"`
+	Document     int               `json:"document"`
+	PathElements []jsonPathElement `json:"pathElements"`
+	Details      []jsonDetail      `json:"details"`
+	Severity     string            `json:"severity,omitempty"`
+}
+
+type jsonPathElement struct {
+	Index *int   `json:"index,omitempty"`
+	Key   string `json:"key,omitempty"`
+	Name  string `json:"name,omitempty"`
+}
+
+type jsonDetail struct {
+	Kind string          `json:"kind"`
+	From json.RawMessage `json:"from,omitempty"`
+	To   json.RawMessage `json:"to,omitempty"`
+}
+
+// WriteReport writes the report as JSON to the provided writer
+func (report *JSONReport) WriteReport(out io.Writer) error {
+	result := jsonReport{
+		From:  toJSONInputFile(report.From),
+		To:    toJSONInputFile(report.To),
+		Diffs: make([]jsonDiff, 0, len(report.Diffs)),
+	}
+
+	for _, diff := range report.Diffs {
+		entry := jsonDiff{
+			Path:         diff.Path.ToGoPatchStyle(),
+			Document:     diff.Path.DocumentIdx,
+			PathElements: make([]jsonPathElement, 0, len(diff.Path.PathElements)),
+		}
+
+		if diff.Severity != SeverityNone {
+			entry.Severity = diff.Severity.String()
+		}
+
+		for _, element := range diff.Path.PathElements {
+			switch {
+			case element.Name != "":
+				entry.PathElements = append(entry.PathElements, jsonPathElement{Key: element.Key, Name: element.Name})
+
+			default:
+				idx := element.Idx
+				entry.PathElements = append(entry.PathElements, jsonPathElement{Index: &idx})
+			}
+		}
+
+		for _, detail := range diff.Details {
+			from, err := nodeToJSON(detail.From)
+			if err != nil {
+				return err
+			}
+
+			to, err := nodeToJSON(detail.To)
+			if err != nil {
+				return err
+			}
+
+			entry.Details = append(entry.Details, jsonDetail{Kind: KindName(detail.Kind), From: from, To: to})
+		}
+
+		result.Diffs = append(result.Diffs, entry)
+	}
+
+	if report.ShowStats {
+		result.Stats = toJSONStats(report.Stats())
+	}
+
+	data, err := json.MarshalIndent(result, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	_, err = out.Write(append(data, '\n'))
+	return err
+}
+
+// LoadReport loads a report from a file that was written using JSONReport
+func LoadReport(location string) (Report, error) {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return Report{}, err
+	}
+
+	report, err := ParseReport(data)
+	if err != nil {
+		return Report{}, fmt.Errorf("failed to parse report %s: %w", location, err)
+	}
+
+	return report, nil
+}
+
+// ParseReport parses a report in the JSON format written by JSONReport. The
+// documents of the input files are not part of the JSON format, which is why
+// the input files of the result only contain empty placeholder documents.
+func ParseReport(data []byte) (Report, error) {
+	var input jsonReport
+	if err := json.Unmarshal(data, &input); err != nil {
+		return Report{}, err
+	}
+
+	result := &Report{
+		From: fromJSONInputFile(input.From),
+		To:   fromJSONInputFile(input.To),
+	}
+
+	for _, entry := range input.Diffs {
+		path := ytbx.Path{Root: &result.From, DocumentIdx: entry.Document}
+		for _, element := range entry.PathElements {
+			switch {
+			case element.Index != nil:
+				path.PathElements = append(path.PathElements, ytbx.PathElement{Idx: *element.Index})
+
+			default:
+				path.PathElements = append(path.PathElements, ytbx.PathElement{Idx: -1, Key: element.Key, Name: element.Name})
+			}
+		}
+
+		diff := Diff{Path: path}
+		if entry.Severity != "" {
+			severity, err := ParseSeverity(entry.Severity)
+			if err != nil {
+				return Report{}, err
+			}
+
+			diff.Severity = severity
+		}
+
+		for _, detail := range entry.Details {
+			kind, err := KindByName(detail.Kind)
+			if err != nil {
+				return Report{}, err
+			}
+
+			from, err := nodeFromJSON(detail.From)
+			if err != nil {
+				return Report{}, err
+			}
+
+			to, err := nodeFromJSON(detail.To)
+			if err != nil {
+				return Report{}, err
+			}
+
+			diff.Details = append(diff.Details, Detail{Kind: kind, From: from, To: to})
+		}
+
+		result.Diffs = append(result.Diffs, diff)
+	}
+
+	return *result, nil
+}
+
+func toJSONStats(stats Stats) *jsonStats {
+	result := &jsonStats{
+		Differences:   stats.Differences,
+		ByKind:        make(map[string]int, len(stats.ByKind)),
+		ByTopLevelKey: stats.ByTopLevelKey,
+		ByResource:    stats.ByResource,
+	}
+
+	for kind, count := range stats.ByKind {
+		result.ByKind[KindName(kind)] = count
+	}
+
+	return result
+}
+
+func toJSONInputFile(inputFile ytbx.InputFile) jsonInputFile {
+	return jsonInputFile{
+		Location:  inputFile.Location,
+		Note:      inputFile.Note,
+		Documents: len(inputFile.Documents),
+		Names:     inputFile.Names,
+	}
+}
+
+func fromJSONInputFile(inputFile jsonInputFile) ytbx.InputFile {
+	return ytbx.InputFile{
+		Location:  inputFile.Location,
+		Note:      inputFile.Note,
+		Documents: make([]*yamlv3.Node, inputFile.Documents),
+		Names:     inputFile.Names,
+	}
+}
+
+// nodeToJSON returns the JSON representation of the node, which keeps the
+// order of the keys in maps
+func nodeToJSON(node *yamlv3.Node) (json.RawMessage, error) {
+	node = followAlias(node)
+	if node == nil {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	switch node.Kind {
+	case yamlv3.DocumentNode:
+		if len(node.Content) == 0 {
+			return nil, nil
+		}
+
+		return nodeToJSON(node.Content[0])
+
+	case yamlv3.MappingNode:
+		buf.WriteString("{")
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			key, err := json.Marshal(followAlias(node.Content[i]).Value)
+			if err != nil {
+				return nil, err
+			}
+
+			value, err := nodeToJSON(node.Content[i+1])
+			if err != nil {
+				return nil, err
+			}
+
+			buf.Write(key)
+			buf.WriteString(":")
+			buf.Write(value)
+		}
+		buf.WriteString("}")
+
+	case yamlv3.SequenceNode:
+		buf.WriteString("[")
+		for i, entry := range node.Content {
+			if i > 0 {
+				buf.WriteString(",")
+			}
+
+			value, err := nodeToJSON(entry)
+			if err != nil {
+				return nil, err
+			}
+
+			buf.Write(value)
+		}
+		buf.WriteString("]")
+
+	default:
+		data, err := json.Marshal(scalarValue(node))
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(data)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// scalarValue returns the value of the scalar node for the JSON output. Values
+// that have no JSON equivalent, for example infinite numbers, timestamps, or
+// binary data, are written as a string of the value as it is in the input.
+func scalarValue(node *yamlv3.Node) interface{} {
+	switch node.ShortTag() {
+	case "!!null":
+		return nil
+
+	case "!!bool", "!!int":
+		var value interface{}
+		if err := node.Decode(&value); err == nil {
+			return value
+		}
+
+	case "!!float":
+		var value float64
+		if err := node.Decode(&value); err == nil && !math.IsInf(value, 0) && !math.IsNaN(value) {
+			return value
+		}
+	}
+
+	return node.Value
+}
+
+// nodeFromJSON parses the JSON data into a node, which keeps the order of the
+// keys in maps, since JSON is a subset of YAML
+func nodeFromJSON(data json.RawMessage) (*yamlv3.Node, error) {
+	if len(data) == 0 {
+		return nil, nil
+	}
+
+	var document yamlv3.Node
+	if err := yamlv3.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+
+	node := documentContent(&document)
+	resetStyle(node)
+	return node, nil
+}
+
+func resetStyle(node *yamlv3.Node) {
+	node.Style = 0
+	for _, entry := range node.Content {
+		resetStyle(entry)
+	}
+}
@@ -21,7 +21,10 @@
 package dyff_test
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
+	"io"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -92,4 +95,176 @@ Miss Foobar`
                  500000`, Sprintf("Lime{#1}"), Sprintf("Blue{#2}"), Sprintf("Aqua{~#3~}"), Sprintf("LemonChiffon{_*#4*_}"))))
 		})
 	})
+
+	Context("writing reports as JSON", func() {
+		It("should write a report that can be loaded again", func() {
+			diffs, err := compare(
+				yml(`{"name": "app", "list": [{"name": "one", "value": 1}], "map": {"z": 1, "a": 2}}`),
+				yml(`{"name": "app", "list": [{"name": "one", "value": 2}], "map": {"z": 2, "b": 3}}`),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			writer := JSONReport{Report: Report{Diffs: diffs}}
+			Expect(writer.WriteReport(&buf)).To(Succeed())
+
+			report, err := ParseReport(buf.Bytes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(len(diffs)))
+			for i := range diffs {
+				Expect(report.Diffs[i]).To(BeSameDiffAs(diffs[i]))
+			}
+
+			Expect(DiffReports(Report{Diffs: diffs}, report).HasChanges()).To(BeFalse())
+		})
+
+		It("should add the statistics to the JSON output if requested", func() {
+			diffs, err := compare(
+				yml(`{"spec": {"replicas": 1, "image": "app:1"}, "status": {"ready": true}}`),
+				yml(`{"spec": {"replicas": 2, "image": "app:2"}, "status": {"ready": true, "phase": "Running"}}`),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			writer := JSONReport{Report: Report{Diffs: diffs}, ShowStats: true}
+			Expect(writer.WriteReport(&buf)).To(Succeed())
+
+			var result struct {
+				Stats struct {
+					Differences   int            `json:"differences"`
+					ByKind        map[string]int `json:"byKind"`
+					ByTopLevelKey map[string]int `json:"byTopLevelKey"`
+				} `json:"stats"`
+			}
+
+			Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+			Expect(result.Stats.Differences).To(Equal(3))
+			Expect(result.Stats.ByKind).To(Equal(map[string]int{"modification": 2, "addition": 1}))
+			Expect(result.Stats.ByTopLevelKey).To(Equal(map[string]int{"spec": 2, "status": 1}))
+
+			report, err := ParseReport(buf.Bytes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(len(diffs)))
+		})
+
+		It("should write values without a JSON equivalent as strings", func() {
+			diffs, err := compare(
+				yml("{inf: 1.0, nan: 1.0, neg: 1.0, time: 2021-01-01, data: !!binary aGVsbG8=, custom: !foo bar}"),
+				yml("{inf: .inf, nan: .nan, neg: -.inf, time: 2022-02-02T10:00:00Z, data: !!binary d29ybGQ=, custom: !foo baz}"),
+			)
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			writer := JSONReport{Report: Report{Diffs: diffs}}
+			Expect(writer.WriteReport(&buf)).To(Succeed())
+
+			var result struct {
+				Diffs []struct {
+					Path    string `json:"path"`
+					Details []struct {
+						From interface{} `json:"from"`
+						To   interface{} `json:"to"`
+					} `json:"details"`
+				} `json:"diffs"`
+			}
+
+			Expect(json.Unmarshal(buf.Bytes(), &result)).To(Succeed())
+
+			values := map[string][]interface{}{}
+			for _, diff := range result.Diffs {
+				values[diff.Path] = []interface{}{diff.Details[0].From, diff.Details[0].To}
+			}
+
+			Expect(values).To(Equal(map[string][]interface{}{
+				"/inf":    {1.0, ".inf"},
+				"/nan":    {1.0, ".nan"},
+				"/neg":    {1.0, "-.inf"},
+				"/time":   {"2021-01-01", "2022-02-02T10:00:00Z"},
+				"/data":   {"aGVsbG8=", "d29ybGQ="},
+				"/custom": {"bar", "baz"},
+			}))
+
+			report, err := ParseReport(buf.Bytes())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(report.Diffs).To(HaveLen(len(diffs)))
+		})
+
+		It("should fail to parse unknown change kinds", func() {
+			_, err := ParseReport([]byte(`{"diffs": [{"path": "/a", "details": [{"kind": "unknown"}]}]}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("using the registry of report styles", func() {
+		It("should create report writers of the built-in styles by name or alias", func() {
+			for name, expected := range map[string]ReportWriter{
+				"human":      &HumanReport{},
+				"BOSH":       &HumanReport{},
+				"summary":    &BriefReport{},
+				"statistics": &StatsReport{},
+				"json":       &JSONReport{},
+			} {
+				writer, err := NewReportWriter(name, Report{}, ReportWriterOptions{})
+				Expect(err).ToNot(HaveOccurred())
+				Expect(writer).To(BeAssignableToTypeOf(expected))
+			}
+
+			Expect(ReportStyleNames()).To(ContainElements("human", "brief", "stats", "json"))
+		})
+
+		It("should use the style specific options with their defaults", func() {
+			writer, err := NewReportWriter("human", Report{}, ReportWriterOptions{OmitHeader: true})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writer.(*HumanReport).OmitHeader).To(BeTrue())
+			Expect(writer.(*HumanReport).MinorChangeThreshold).To(Equal(0.1))
+
+			writer, err = NewReportWriter("human", Report{}, ReportWriterOptions{Settings: map[string]string{"minor-change-threshold": "0.5"}})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(writer.(*HumanReport).MinorChangeThreshold).To(Equal(0.5))
+
+			_, err = NewReportWriter("brief", Report{}, ReportWriterOptions{Settings: map[string]string{"minor-change-threshold": "0.5"}})
+			Expect(err).To(MatchError("output style brief does not support option minor-change-threshold"))
+
+			_, err = NewReportWriter("unknown", Report{}, ReportWriterOptions{})
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("should register additional report styles", func() {
+			Expect(RegisterReportStyle(ReportStyle{
+				Name:    "paths-only",
+				Options: []ReportStyleOption{{Name: "prefix", Default: "-"}},
+				New: func(report Report, options ReportWriterOptions) (ReportWriter, error) {
+					return &pathsOnlyReport{Report: report, prefix: options.Settings["prefix"]}, nil
+				},
+			})).To(Succeed())
+			defer UnregisterReportStyle("paths-only")
+
+			Expect(RegisterReportStyle(ReportStyle{Name: "paths-only", New: func(Report, ReportWriterOptions) (ReportWriter, error) { return nil, nil }})).ToNot(Succeed())
+			Expect(RegisterReportStyle(ReportStyle{Name: "other", Aliases: []string{"json"}, New: func(Report, ReportWriterOptions) (ReportWriter, error) { return nil, nil }})).ToNot(Succeed())
+			Expect(RegisterReportStyle(ReportStyle{Name: "incomplete"})).ToNot(Succeed())
+
+			diffs, err := compare(yml(`{"a": 1, "b": 2}`), yml(`{"a": 2, "b": 3}`))
+			Expect(err).ToNot(HaveOccurred())
+
+			writer, err := NewReportWriter("paths-only", Report{Diffs: diffs}, ReportWriterOptions{})
+			Expect(err).ToNot(HaveOccurred())
+
+			var buf bytes.Buffer
+			Expect(writer.WriteReport(&buf)).To(Succeed())
+			Expect(buf.String()).To(Equal("- /a\n- /b\n"))
+		})
+	})
 })
+
+type pathsOnlyReport struct {
+	Report
+	prefix string
+}
+
+func (report *pathsOnlyReport) WriteReport(out io.Writer) error {
+	for _, diff := range report.Diffs {
+		fmt.Fprintf(out, "%s %s\n", report.prefix, diff.Path.ToGoPatchStyle())
+	}
+
+	return nil
+}
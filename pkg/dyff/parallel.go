@@ -0,0 +1,193 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// progressInterval is the number of compared nodes after which the progress
+// callback is called while a document is compared
+const progressInterval = 1000
+
+// parallelSubtreeThreshold is the number of nodes a subtree needs to have so
+// that it is compared concurrently, smaller subtrees are not worth the effort
+const parallelSubtreeThreshold = 1000
+
+// Workers sets the number of workers that compare documents and large subtrees
+// concurrently. The differences in the report are in the same order, no matter
+// how many workers are used. With less than two workers, the comparison runs
+// sequentially, which is the default. With more workers, custom comparators
+// have to be safe for concurrent use.
+func Workers(workers int) CompareOption {
+	return func(settings *compareSettings) {
+		settings.Workers = workers
+	}
+}
+
+// Progress describes how far a comparison got, it is passed to the progress
+// callback after each document, and periodically while a document is compared
+type Progress struct {
+	Documents     int
+	DocumentsDone int
+	NodesCompared int
+}
+
+// progressTracker counts the progress of concurrent comparisons using atomic
+// counters, the lock is only held while the callback is called, so that the
+// callback does not need to be thread-safe
+type progressTracker struct {
+	sync.Mutex
+	documents     int
+	documentsDone int64
+	nodesCompared int64
+}
+
+// nodeCompared counts one compared node and returns the number of nodes that
+// were compared so far
+func (tracker *progressTracker) nodeCompared(callback func(Progress)) int64 {
+	count := atomic.AddInt64(&tracker.nodesCompared, 1)
+	if callback != nil && count%progressInterval == 0 {
+		tracker.report(callback)
+	}
+
+	return count
+}
+
+func (tracker *progressTracker) documentDone(callback func(Progress)) {
+	atomic.AddInt64(&tracker.documentsDone, 1)
+	if callback != nil {
+		tracker.report(callback)
+	}
+}
+
+func (tracker *progressTracker) report(callback func(Progress)) {
+	tracker.Lock()
+	defer tracker.Unlock()
+
+	callback(Progress{
+		Documents:     tracker.documents,
+		DocumentsDone: int(atomic.LoadInt64(&tracker.documentsDone)),
+		NodesCompared: int(atomic.LoadInt64(&tracker.nodesCompared)),
+	})
+}
+
+// comparison is a pair of nodes that need to be compared with each other
+type comparison struct {
+	path ytbx.Path
+	from *yamlv3.Node
+	to   *yamlv3.Node
+}
+
+// all compares all given pairs of nodes and returns the differences in the
+// order of the pairs. If workers are configured, pairs for which concurrent
+// returns true are compared in a worker, as long as one is available, all other
+// pairs are compared right away. The optional done function is called after
+// each pair.
+func (compare *compare) all(comparisons []comparison, concurrent func(comparison) bool, done func()) ([]Diff, error) {
+	results := make([][]Diff, len(comparisons))
+	errs := make([]error, len(comparisons))
+
+	run := func(idx int) {
+		results[idx], errs[idx] = compare.objects(comparisons[idx].path, comparisons[idx].from, comparisons[idx].to)
+		if done != nil {
+			done()
+		}
+	}
+
+	var wg sync.WaitGroup
+	for idx := range comparisons {
+		if compare.workers != nil && concurrent(comparisons[idx]) && compare.tryAcquireWorker() {
+			wg.Add(1)
+			go func(idx int) {
+				defer wg.Done()
+				defer compare.releaseWorker()
+				run(idx)
+			}(idx)
+
+			continue
+		}
+
+		run(idx)
+		if errs[idx] != nil {
+			break
+		}
+	}
+
+	wg.Wait()
+
+	result := make([]Diff, 0)
+	for idx := range comparisons {
+		if errs[idx] != nil {
+			return nil, errs[idx]
+		}
+
+		result = append(result, results[idx]...)
+	}
+
+	return result, nil
+}
+
+// largeSubtree returns whether at least one of the nodes of the comparison
+// has enough child nodes to be compared concurrently
+func (compare *compare) largeSubtree(comparison comparison) bool {
+	_, largeFrom := compare.largeSubtrees[comparison.from]
+	_, largeTo := compare.largeSubtrees[comparison.to]
+	return largeFrom || largeTo
+}
+
+// recordLargeSubtrees counts the nodes of the tree once, and records all nodes
+// that are large enough to be compared concurrently, so that the size of the
+// subtrees does not need to be counted again on each level of the comparison
+func recordLargeSubtrees(node *yamlv3.Node, largeSubtrees map[*yamlv3.Node]struct{}) int {
+	if node == nil {
+		return 0
+	}
+
+	count := 1
+	for _, child := range node.Content {
+		count += recordLargeSubtrees(child, largeSubtrees)
+	}
+
+	if count >= parallelSubtreeThreshold {
+		largeSubtrees[node] = struct{}{}
+	}
+
+	return count
+}
+
+func (compare *compare) tryAcquireWorker() bool {
+	select {
+	case compare.workers <- struct{}{}:
+		return true
+
+	default:
+		return false
+	}
+}
+
+func (compare *compare) releaseWorker() {
+	<-compare.workers
+}
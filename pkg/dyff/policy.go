@@ -0,0 +1,402 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+	"github.com/lucasb-eyer/go-colorful"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Severity describes how relevant a difference is according to a policy
+type Severity int
+
+// Supported severities, where the zero value means that the difference was
+// not classified by any policy rule
+const (
+	SeverityNone Severity = iota
+	SeverityInfo
+	SeverityWarning
+	SeverityError
+)
+
+// String returns the name of the severity
+func (s Severity) String() string {
+	switch s {
+	case SeverityInfo:
+		return "info"
+
+	case SeverityWarning:
+		return "warning"
+
+	case SeverityError:
+		return "error"
+	}
+
+	return "none"
+}
+
+// ParseSeverity returns the severity with the given name
+func ParseSeverity(name string) (Severity, error) {
+	for _, severity := range []Severity{SeverityNone, SeverityInfo, SeverityWarning, SeverityError} {
+		if strings.EqualFold(name, severity.String()) {
+			return severity, nil
+		}
+	}
+
+	return SeverityNone, fmt.Errorf("unknown severity %s, supported severities are: info, warning, and error", name)
+}
+
+// PolicyRule classifies the differences that match all of its conditions with
+// its severity. The path is a Go-Patch style pattern, where a star matches one
+// path element, two stars match any number of path elements, and all other
+// elements can contain glob patterns, for example /spec/**/image. A slash
+// that is part of a key is written as ~1, and a tilde as ~0, for example
+// /metadata/annotations/app.kubernetes.io~1name. The kind is
+// the name of the change kind, i.e. addition, removal, modification, or order
+// change. The change can be increase or decrease, to only match modifications
+// of numbers where the value increases or decreases. The from and to regular
+// expressions need to match the respective value.
+//
+// Map entries that are added or removed are matched by their own path, so that
+// a rule with the path /**/securityContext and the kind removal matches the
+// removal of a security context.
+type PolicyRule struct {
+	Path      string `yaml:"path"`
+	Kind      string `yaml:"kind,omitempty"`
+	Change    string `yaml:"change,omitempty"`
+	FromRegex string `yaml:"fromRegex,omitempty"`
+	ToRegex   string `yaml:"toRegex,omitempty"`
+	Severity  string `yaml:"severity"`
+
+	severity  Severity
+	kind      rune
+	pattern   []string
+	fromRegex *regexp.Regexp
+	toRegex   *regexp.Regexp
+}
+
+// Policy is a list of rules to classify differences by severity
+type Policy struct {
+	Rules []PolicyRule `yaml:"rules"`
+}
+
+// LoadPolicy loads a policy file, which lists the rules to classify the
+// differences by severity
+func LoadPolicy(location string) (Policy, error) {
+	data, err := ioutil.ReadFile(location)
+	if err != nil {
+		return Policy{}, err
+	}
+
+	var policy Policy
+	if err := yamlv3.Unmarshal(data, &policy); err != nil {
+		return Policy{}, fmt.Errorf("failed to parse policy file %s: %w", location, err)
+	}
+
+	for i := range policy.Rules {
+		if err := policy.Rules[i].compile(); err != nil {
+			return Policy{}, fmt.Errorf("failed to parse policy file %s: %w", location, err)
+		}
+	}
+
+	return policy, nil
+}
+
+// NewPolicyRule prepares the given rule to be used in a policy, which fails if
+// the severity, kind, change, or the regular expressions are invalid
+func NewPolicyRule(rule PolicyRule) (PolicyRule, error) {
+	return rule, rule.compile()
+}
+
+func (rule *PolicyRule) compile() error {
+	severity, err := ParseSeverity(rule.Severity)
+	if err != nil {
+		return fmt.Errorf("policy rule of path %s: %w", rule.Path, err)
+	}
+
+	if severity == SeverityNone {
+		return fmt.Errorf("policy rule of path %s without a severity", rule.Path)
+	}
+
+	rule.severity = severity
+
+	if rule.Kind != "" {
+		kind, err := KindByName(rule.Kind)
+		if err != nil {
+			return fmt.Errorf("policy rule of path %s: %w", rule.Path, err)
+		}
+
+		rule.kind = kind
+	}
+
+	switch rule.Change {
+	case "", "increase", "decrease":
+
+	default:
+		return fmt.Errorf("policy rule of path %s with unknown change %s, supported changes are: increase, and decrease", rule.Path, rule.Change)
+	}
+
+	rule.pattern = splitPolicyPath(rule.Path)
+	for _, element := range rule.pattern {
+		if _, err := filepath.Match(element, ""); err != nil {
+			return fmt.Errorf("policy rule with invalid path pattern %s: %w", rule.Path, err)
+		}
+	}
+
+	for _, expr := range []struct {
+		source string
+		target **regexp.Regexp
+	}{
+		{rule.FromRegex, &rule.fromRegex},
+		{rule.ToRegex, &rule.toRegex},
+	} {
+		if expr.source == "" {
+			continue
+		}
+
+		regex, err := regexp.Compile(expr.source)
+		if err != nil {
+			return fmt.Errorf("policy rule of path %s with invalid regular expression: %w", rule.Path, err)
+		}
+
+		*expr.target = regex
+	}
+
+	return nil
+}
+
+// Classify returns a new report, in which the severity of each difference is
+// set to the highest severity of all matching policy rules
+func (r Report) Classify(policy Policy) Report {
+	return r.Map(func(diff Diff) Diff {
+		diff.Severity = SeverityNone
+		for _, rule := range policy.Rules {
+			if rule.severity > diff.Severity && rule.Matches(diff) {
+				diff.Severity = rule.severity
+			}
+		}
+
+		return diff
+	})
+}
+
+// MaxSeverity returns the highest severity of all differences in the report
+func (r Report) MaxSeverity() Severity {
+	var max Severity
+	for _, diff := range r.Diffs {
+		if diff.Severity > max {
+			max = diff.Severity
+		}
+	}
+
+	return max
+}
+
+// sortBySeverity returns the differences with the highest severity first,
+// while differences of the same severity keep their order
+func sortBySeverity(diffs []Diff) []Diff {
+	result := make([]Diff, len(diffs))
+	copy(result, diffs)
+
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Severity > result[j].Severity
+	})
+
+	return result
+}
+
+// severityColor returns the theme color used for the severity label
+func severityColor(severity Severity) colorful.Color {
+	switch severity {
+	case SeverityError:
+		return currentTheme.Removal
+
+	case SeverityWarning:
+		return currentTheme.Modification
+	}
+
+	return currentTheme.Addition
+}
+
+// Matches returns whether the rule matches any change of the difference
+func (rule PolicyRule) Matches(diff Diff) bool {
+	for _, detail := range diff.Details {
+		if rule.kind != 0 && rule.kind != detail.Kind {
+			continue
+		}
+
+		for _, change := range policyChanges(diff.Path, detail) {
+			if matchesPolicyPath(rule.pattern, change.path) &&
+				rule.matchesValues(change.from, change.to) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func (rule PolicyRule) matchesValues(from *yamlv3.Node, to *yamlv3.Node) bool {
+	if rule.fromRegex != nil && (from == nil || !rule.fromRegex.MatchString(valueText(from))) {
+		return false
+	}
+
+	if rule.toRegex != nil && (to == nil || !rule.toRegex.MatchString(valueText(to))) {
+		return false
+	}
+
+	if rule.Change != "" {
+		if from == nil || to == nil {
+			return false
+		}
+
+		fromValue, err := strconv.ParseFloat(followAlias(from).Value, 64)
+		if err != nil {
+			return false
+		}
+
+		toValue, err := strconv.ParseFloat(followAlias(to).Value, 64)
+		if err != nil {
+			return false
+		}
+
+		switch rule.Change {
+		case "increase":
+			return toValue > fromValue
+
+		case "decrease":
+			return toValue < fromValue
+		}
+	}
+
+	return true
+}
+
+type policyChange struct {
+	path []string
+	from *yamlv3.Node
+	to   *yamlv3.Node
+}
+
+// policyChanges lists the changes of a detail that policy rules are matched
+// against, which is the detail itself, and in case map entries were added or
+// removed, each of these entries with its own path
+func policyChanges(path ytbx.Path, detail Detail) []policyChange {
+	elements := pathElementStrings(path)
+	result := []policyChange{{path: elements, from: detail.From, to: detail.To}}
+
+	var node *yamlv3.Node
+	switch detail.Kind {
+	case ADDITION:
+		node = followAlias(detail.To)
+
+	case REMOVAL:
+		node = followAlias(detail.From)
+	}
+
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return result
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		change := policyChange{path: append(elements[:len(elements):len(elements)], node.Content[i].Value)}
+		if detail.Kind == ADDITION {
+			change.to = node.Content[i+1]
+		} else {
+			change.from = node.Content[i+1]
+		}
+
+		result = append(result, change)
+	}
+
+	return result
+}
+
+// splitPolicyPath splits the Go-Patch style pattern into its elements, where
+// ~1 stands for a slash and ~0 for a tilde inside of an element
+func splitPolicyPath(path string) []string {
+	path = strings.Trim(path, "/")
+	if path == "" {
+		return nil
+	}
+
+	unescape := strings.NewReplacer("~1", "/", "~0", "~")
+	elements := strings.Split(path, "/")
+	for i := range elements {
+		elements[i] = unescape.Replace(elements[i])
+	}
+
+	return elements
+}
+
+// pathElementStrings returns the elements of the path as they are written in
+// a Go-Patch style path, without splitting keys that contain a slash
+func pathElementStrings(path ytbx.Path) []string {
+	result := make([]string, len(path.PathElements))
+	for i, element := range path.PathElements {
+		switch {
+		case element.Name != "" && element.Key == "":
+			result[i] = element.Name
+
+		case element.Name != "" && element.Key != "":
+			result[i] = element.Key + "=" + element.Name
+
+		default:
+			result[i] = strconv.Itoa(element.Idx)
+		}
+	}
+
+	return result
+}
+
+func matchesPolicyPath(pattern []string, elements []string) bool {
+	switch {
+	case len(pattern) == 0:
+		return len(elements) == 0
+
+	case pattern[0] == "**":
+		for i := 0; i <= len(elements); i++ {
+			if matchesPolicyPath(pattern[1:], elements[i:]) {
+				return true
+			}
+		}
+
+		return false
+
+	case len(elements) == 0:
+		return false
+	}
+
+	if matched, _ := filepath.Match(pattern[0], elements[0]); !matched {
+		return false
+	}
+
+	return matchesPolicyPath(pattern[1:], elements[1:])
+}
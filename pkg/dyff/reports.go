@@ -1,6 +1,11 @@
 package dyff
 
-import "github.com/gonvenience/ytbx"
+import (
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/ytbx"
+)
 
 // Filter accepts YAML paths as input and returns a new report with differences for those paths only
 func (r Report) Filter(paths ...ytbx.Path) (result Report) {
@@ -28,3 +33,195 @@ func (r Report) Filter(paths ...ytbx.Path) (result Report) {
 
 	return result
 }
+
+// Visitor has one callback per change kind, which are called for each detail
+// of the respective kind. Callbacks that are not set are skipped.
+type Visitor struct {
+	Addition     func(diff Diff, detail Detail) error
+	Removal      func(diff Diff, detail Detail) error
+	Modification func(diff Diff, detail Detail) error
+	OrderChange  func(diff Diff, detail Detail) error
+}
+
+// Visit calls the callback of the visitor for each detail of all differences
+// in the order of the report, and stops at the first error
+func (r Report) Visit(visitor Visitor) error {
+	for _, diff := range r.Diffs {
+		for _, detail := range diff.Details {
+			var callback func(Diff, Detail) error
+			switch detail.Kind {
+			case ADDITION:
+				callback = visitor.Addition
+
+			case REMOVAL:
+				callback = visitor.Removal
+
+			case MODIFICATION:
+				callback = visitor.Modification
+
+			case ORDERCHANGE:
+				callback = visitor.OrderChange
+			}
+
+			if callback == nil {
+				continue
+			}
+
+			if err := callback(diff, detail); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// DiffGroup is a named group of differences
+type DiffGroup struct {
+	Key   string
+	Diffs []Diff
+}
+
+// GroupBy groups the differences by the key function, the groups are in the
+// order of the first difference of each group
+func (r Report) GroupBy(key func(Diff) string) []DiffGroup {
+	var groups []DiffGroup
+	index := map[string]int{}
+	for _, diff := range r.Diffs {
+		k := key(diff)
+		idx, ok := index[k]
+		if !ok {
+			idx = len(groups)
+			index[k] = idx
+			groups = append(groups, DiffGroup{Key: k})
+		}
+
+		groups[idx].Diffs = append(groups[idx].Diffs, diff)
+	}
+
+	return groups
+}
+
+// GroupByDocument groups the differences by the document they belong to,
+// using the name of the document if available
+func (r Report) GroupByDocument() []DiffGroup {
+	return r.GroupBy(func(diff Diff) string {
+		return diff.Path.RootDescription()
+	})
+}
+
+// GroupByPathPrefix groups the differences by the first path elements, up to
+// the given depth, using Go-Patch style paths as the key. Differences with a
+// shorter path are grouped by their complete path.
+func (r Report) GroupByPathPrefix(depth int) []DiffGroup {
+	return r.GroupBy(func(diff Diff) string {
+		prefix := diff.Path
+		if len(prefix.PathElements) > depth {
+			prefix.PathElements = prefix.PathElements[:depth]
+		}
+
+		return prefix.ToGoPatchStyle()
+	})
+}
+
+// SortByPath returns a new report with the differences sorted by document, and
+// then by path, where list indices are sorted numerically
+func (r Report) SortByPath() Report {
+	result := Report{
+		From:  r.From,
+		To:    r.To,
+		Diffs: make([]Diff, len(r.Diffs)),
+	}
+
+	copy(result.Diffs, r.Diffs)
+	sort.SliceStable(result.Diffs, func(i, j int) bool {
+		return comparePaths(result.Diffs[i].Path, result.Diffs[j].Path) < 0
+	})
+
+	return result
+}
+
+// SortBySeverity returns a new report with the most severe differences first,
+// while differences of the same severity keep their order
+func (r Report) SortBySeverity() Report {
+	return Report{
+		From:  r.From,
+		To:    r.To,
+		Diffs: sortBySeverity(r.Diffs),
+	}
+}
+
+// Map returns a new report with the result of the function for each difference
+func (r Report) Map(f func(Diff) Diff) Report {
+	result := Report{
+		From:  r.From,
+		To:    r.To,
+		Diffs: make([]Diff, len(r.Diffs)),
+	}
+
+	for i, diff := range r.Diffs {
+		result.Diffs[i] = f(diff)
+	}
+
+	return result
+}
+
+// FilterFunc returns a new report with the differences for which the
+// predicate returns true
+func (r Report) FilterFunc(predicate func(Diff) bool) Report {
+	matching, _ := r.Partition(predicate)
+	return matching
+}
+
+// Partition splits the report into one report with the differences for which
+// the predicate returns true, and one report with all other differences
+func (r Report) Partition(predicate func(Diff) bool) (matching Report, rest Report) {
+	matching = Report{From: r.From, To: r.To}
+	rest = Report{From: r.From, To: r.To}
+
+	for _, diff := range r.Diffs {
+		if predicate(diff) {
+			matching.Diffs = append(matching.Diffs, diff)
+		} else {
+			rest.Diffs = append(rest.Diffs, diff)
+		}
+	}
+
+	return matching, rest
+}
+
+// comparePaths compares two paths by document, and then element by element
+func comparePaths(a ytbx.Path, b ytbx.Path) int {
+	if a.DocumentIdx != b.DocumentIdx {
+		return a.DocumentIdx - b.DocumentIdx
+	}
+
+	for i := 0; i < len(a.PathElements) && i < len(b.PathElements); i++ {
+		x, y := a.PathElements[i], b.PathElements[i]
+		switch {
+		case x.Name == "" && y.Name == "":
+			if x.Idx != y.Idx {
+				return x.Idx - y.Idx
+			}
+
+		case x.Name == "" || y.Name == "":
+			// list indices before names
+			if x.Name == "" {
+				return -1
+			}
+
+			return 1
+
+		default:
+			if c := strings.Compare(x.Key, y.Key); c != 0 {
+				return c
+			}
+
+			if c := strings.Compare(x.Name, y.Name); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return len(a.PathElements) - len(b.PathElements)
+}
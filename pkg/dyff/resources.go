@@ -0,0 +1,328 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// ResourceID identifies a Kubernetes resource independent of the file or
+// document it is defined in
+type ResourceID struct {
+	APIVersion string
+	Kind       string
+	Namespace  string
+	Name       string
+}
+
+// String returns a human readable representation of the resource identity,
+// for example: Deployment/default/app (apps/v1)
+func (id ResourceID) String() string {
+	var name = id.Name
+	if id.Namespace != "" {
+		name = id.Namespace + "/" + id.Name
+	}
+
+	return fmt.Sprintf("%s/%s (%s)", id.Kind, name, id.APIVersion)
+}
+
+// Resource is a Kubernetes resource document together with the location it was
+// loaded from
+type Resource struct {
+	ID       ResourceID
+	Location string
+	Document *yamlv3.Node
+}
+
+// ResourceReport is the comparison result of one Kubernetes resource, where
+// the kind is ADDITION or REMOVAL for resources that only exist on one side,
+// and MODIFICATION for resources that exist on both sides
+type ResourceReport struct {
+	ID           ResourceID
+	Kind         rune
+	FromLocation string
+	ToLocation   string
+
+	Report
+}
+
+// ResourceSetReport is the comparison result of two sets of Kubernetes
+// resources with one resource report per resource identity
+type ResourceSetReport struct {
+	From      []string
+	To        []string
+	Resources []ResourceReport
+}
+
+// HasDifferences returns whether resources were added or removed, or whether
+// resources that exist on both sides have differences
+func (r ResourceSetReport) HasDifferences() bool {
+	for _, resource := range r.Resources {
+		if resource.Kind != MODIFICATION || len(resource.Diffs) > 0 {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CompareResourceSets loads all Kubernetes resources from the provided files
+// or directories on each side and compares them by their identity, that is
+// the combination of apiVersion, kind, namespace, and name. The file or the
+// position of a resource inside a file is not relevant for the comparison.
+func CompareResourceSets(from []string, to []string, filter DirectoryFilter, compareOptions ...CompareOption) (ResourceSetReport, error) {
+	fromResources, err := LoadResources(filter, from...)
+	if err != nil {
+		return ResourceSetReport{}, err
+	}
+
+	toResources, err := LoadResources(filter, to...)
+	if err != nil {
+		return ResourceSetReport{}, err
+	}
+
+	return CompareResources(from, to, fromResources, toResources, compareOptions...)
+}
+
+// CompareResources compares two lists of Kubernetes resources by their
+// identity, the provided locations are only used as a reference in the result
+func CompareResources(fromLocations []string, toLocations []string, from []Resource, to []Resource, compareOptions ...CompareOption) (ResourceSetReport, error) {
+	fromSet, err := resourceSet(from)
+	if err != nil {
+		return ResourceSetReport{}, err
+	}
+
+	toSet, err := resourceSet(to)
+	if err != nil {
+		return ResourceSetReport{}, err
+	}
+
+	ids := make([]ResourceID, 0, len(fromSet)+len(toSet))
+	for id := range fromSet {
+		ids = append(ids, id)
+	}
+
+	for id := range toSet {
+		if _, ok := fromSet[id]; !ok {
+			ids = append(ids, id)
+		}
+	}
+
+	sort.Slice(ids, func(i, j int) bool {
+		return ids[i].String() < ids[j].String()
+	})
+
+	result := ResourceSetReport{From: fromLocations, To: toLocations}
+	for _, id := range ids {
+		fromResource, inFrom := fromSet[id]
+		toResource, inTo := toSet[id]
+
+		switch {
+		case inFrom && !inTo:
+			result.Resources = append(result.Resources, ResourceReport{ID: id, Kind: REMOVAL, FromLocation: fromResource.Location})
+
+		case !inFrom && inTo:
+			result.Resources = append(result.Resources, ResourceReport{ID: id, Kind: ADDITION, ToLocation: toResource.Location})
+
+		default:
+			report, err := CompareInputFiles(
+				ytbx.InputFile{Location: fromResource.Location, Documents: []*yamlv3.Node{fromResource.Document}},
+				ytbx.InputFile{Location: toResource.Location, Documents: []*yamlv3.Node{toResource.Document}},
+				compareOptions...,
+			)
+
+			if err != nil {
+				return ResourceSetReport{}, fmt.Errorf("failed to compare %s: %w", id, err)
+			}
+
+			result.Resources = append(result.Resources, ResourceReport{
+				ID:           id,
+				Kind:         MODIFICATION,
+				FromLocation: fromResource.Location,
+				ToLocation:   toResource.Location,
+				Report:       report,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// LoadResources loads all Kubernetes resources from the provided files or
+// directories. Directories are searched recursively for YAML and JSON files
+// that match the filter. Items of a Kubernetes List are treated as individual
+// resources, and documents that are not Kubernetes resources are skipped.
+func LoadResources(filter DirectoryFilter, locations ...string) ([]Resource, error) {
+	if err := filter.validate(); err != nil {
+		return nil, err
+	}
+
+	var files []string
+	for _, location := range locations {
+		if !isDir(location) {
+			files = append(files, location)
+			continue
+		}
+
+		paths, err := listDocumentFiles(location, filter)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read files in directory %s: %w", location, err)
+		}
+
+		var list []string
+		for path := range paths {
+			list = append(list, filepath.Join(location, path))
+		}
+
+		sort.Strings(list)
+		files = append(files, list...)
+	}
+
+	var result []Resource
+	for _, file := range files {
+		inputFile, err := ytbx.LoadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load %s: %w", file, err)
+		}
+
+		result = append(result, InputFileResources(inputFile)...)
+	}
+
+	return result, nil
+}
+
+// InputFileResources returns all Kubernetes resources of the input file. Items
+// of a Kubernetes List are treated as individual resources, and documents that
+// are not Kubernetes resources are skipped.
+func InputFileResources(inputFile ytbx.InputFile) []Resource {
+	var result []Resource
+	for idx, document := range inputFile.Documents {
+		location := inputFile.Location
+		if len(inputFile.Documents) > 1 {
+			location = fmt.Sprintf("%s (document #%d)", inputFile.Location, idx+1)
+		}
+
+		result = append(result, resourcesOf(location, document)...)
+	}
+
+	return result
+}
+
+// IsKubernetesList returns whether the document is a Kubernetes List, which
+// contains other resources in its items
+func IsKubernetesList(document *yamlv3.Node) bool {
+	node := documentContent(document)
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return false
+	}
+
+	kind, ok := findValueByKey(node, "kind")
+	return ok && kind.Value == "List"
+}
+
+// resourcesOf returns the resource of the given document, or all items in case
+// the document is a Kubernetes List
+func resourcesOf(location string, document *yamlv3.Node) []Resource {
+	node := document
+	if node != nil && node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return nil
+	}
+
+	if IsKubernetesList(node) {
+		var result []Resource
+		if items, ok := findValueByKey(node, "items"); ok && items.Kind == yamlv3.SequenceNode {
+			for _, item := range items.Content {
+				result = append(result, resourcesOf(location, item)...)
+			}
+		}
+
+		return result
+	}
+
+	id, ok := resourceID(node)
+	if !ok {
+		return nil
+	}
+
+	return []Resource{{
+		ID:       id,
+		Location: location,
+		Document: &yamlv3.Node{Kind: yamlv3.DocumentNode, Content: []*yamlv3.Node{node}},
+	}}
+}
+
+// resourceID returns the identity of the provided mapping node, or false if
+// the node does not have all required fields of a Kubernetes resource
+func resourceID(node *yamlv3.Node) (ResourceID, bool) {
+	var id ResourceID
+	for field, target := range map[ListItemIdentifierField]*string{
+		"apiVersion":    &id.APIVersion,
+		"kind":          &id.Kind,
+		"metadata.name": &id.Name,
+	} {
+		value, err := nameFromPath(node, field)
+		if err != nil || value == "" {
+			return ResourceID{}, false
+		}
+
+		*target = value
+	}
+
+	if namespace, err := nameFromPath(node, "metadata.namespace"); err == nil {
+		id.Namespace = namespace
+	}
+
+	return id, true
+}
+
+// resourceSet returns the resources by their identity and fails in case the
+// same resource is defined more than once
+func resourceSet(resources []Resource) (map[ResourceID]Resource, error) {
+	result := make(map[ResourceID]Resource, len(resources))
+	for _, resource := range resources {
+		if existing, ok := result[resource.ID]; ok {
+			return nil, fmt.Errorf("resource %s is defined more than once: in %s and in %s",
+				resource.ID,
+				existing.Location,
+				resource.Location,
+			)
+		}
+
+		result[resource.ID] = resource
+	}
+
+	return result, nil
+}
+
+func isDir(location string) bool {
+	info, err := os.Stat(location)
+	return err == nil && info.IsDir()
+}
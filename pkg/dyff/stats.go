@@ -0,0 +1,271 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/neat"
+	"github.com/gonvenience/text"
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Stats summarizes the differences of a report by different criteria
+type Stats struct {
+	// Differences is the number of differences (paths) in the report
+	Differences int
+
+	// ByKind counts the details of all differences by their change kind
+	ByKind map[rune]int
+
+	// ByTopLevelKey counts the differences by the first element of their path
+	ByTopLevelKey map[string]int
+
+	// ByResource counts the differences by Kubernetes resource, which is only
+	// available for documents that can be identified as a Kubernetes resource
+	ByResource map[string]int
+}
+
+// StatsReport is a reporter that only prints the statistics of a report
+type StatsReport struct {
+	Report
+}
+
+// changeKinds lists all change kinds in the order they are reported, the
+// order also defines the bit of each change kind (see KindBit)
+var changeKinds = []rune{ADDITION, REMOVAL, MODIFICATION, ORDERCHANGE}
+
+// KindName returns the human readable name of the given change kind
+func KindName(kind rune) string {
+	switch kind {
+	case ADDITION:
+		return "addition"
+
+	case REMOVAL:
+		return "removal"
+
+	case MODIFICATION:
+		return "modification"
+
+	case ORDERCHANGE:
+		return "order change"
+	}
+
+	return "unknown"
+}
+
+// KindByName returns the change kind with the given human readable name, where
+// dashes can be used instead of spaces, for example order-change
+func KindByName(name string) (rune, error) {
+	for _, kind := range changeKinds {
+		if KindName(kind) == strings.ReplaceAll(name, "-", " ") {
+			return kind, nil
+		}
+	}
+
+	return 0, fmt.Errorf("unknown change kind %s", name)
+}
+
+// KindBit returns the bit of the change kind, which is used to describe a set
+// of change kinds as a bitmask. The values are stable and can be relied upon:
+// addition is 1, removal is 2, modification is 4, and order change is 8.
+func KindBit(kind rune) int {
+	for i, candidate := range changeKinds {
+		if candidate == kind {
+			return 1 << i
+		}
+	}
+
+	return 0
+}
+
+// KindMask returns the bitmask of all change kinds found in the report
+func (r Report) KindMask() int {
+	var mask int
+	for _, diff := range r.Diffs {
+		for _, detail := range diff.Details {
+			mask |= KindBit(detail.Kind)
+		}
+	}
+
+	return mask
+}
+
+// Stats returns the statistics of the differences in the report
+func (r Report) Stats() Stats {
+	stats := Stats{
+		Differences:   len(r.Diffs),
+		ByKind:        map[rune]int{},
+		ByTopLevelKey: map[string]int{},
+		ByResource:    map[string]int{},
+	}
+
+	for _, diff := range r.Diffs {
+		for _, detail := range diff.Details {
+			stats.ByKind[detail.Kind]++
+		}
+
+		stats.ByTopLevelKey[topLevelKey(diff.Path)]++
+
+		if resource, ok := r.resourceName(diff.Path.DocumentIdx); ok {
+			stats.ByResource[resource]++
+		}
+	}
+
+	return stats
+}
+
+func topLevelKey(path ytbx.Path) string {
+	if len(path.PathElements) == 0 {
+		return "(root level)"
+	}
+
+	element := path.PathElements[0]
+	if element.Name != "" {
+		return element.Name
+	}
+
+	return strconv.Itoa(element.Idx)
+}
+
+// resourceName returns the Kubernetes resource identifier (kind, namespace,
+// and name) of the document with the given index, preferably based on the
+// from input file, or the to input file if it is not available there
+func (r Report) resourceName(documentIdx int) (string, bool) {
+	for _, inputFile := range []ytbx.InputFile{r.From, r.To} {
+		if documentIdx < 0 || documentIdx >= len(inputFile.Documents) {
+			continue
+		}
+
+		if name, ok := kubernetesResourceName(inputFile.Documents[documentIdx]); ok {
+			return name, true
+		}
+	}
+
+	return "", false
+}
+
+func kubernetesResourceName(document *yamlv3.Node) (string, bool) {
+	node := document
+	if node != nil && node.Kind == yamlv3.DocumentNode && len(node.Content) > 0 {
+		node = node.Content[0]
+	}
+
+	if node == nil || node.Kind != yamlv3.MappingNode {
+		return "", false
+	}
+
+	kind, err := getValueByKey(node, "kind")
+	if err != nil || kind.Kind != yamlv3.ScalarNode {
+		return "", false
+	}
+
+	name, err := nameFromPath(node, "metadata.name")
+	if err != nil || name == "" {
+		return "", false
+	}
+
+	if namespace, err := nameFromPath(node, "metadata.namespace"); err == nil && namespace != "" {
+		return fmt.Sprintf("%s/%s/%s", kind.Value, namespace, name), true
+	}
+
+	return fmt.Sprintf("%s/%s", kind.Value, name), true
+}
+
+// WriteReport writes the statistics of the report to the provided writer
+func (report *StatsReport) WriteReport(out io.Writer) error {
+	writer := bufio.NewWriter(out)
+	defer writer.Flush()
+
+	if err := writeStats(writer, report.Stats()); err != nil {
+		return err
+	}
+
+	// Finish with one last newline so that we do not end next to the prompt
+	writer.WriteString("\n")
+	return nil
+}
+
+func writeStats(out stringWriter, stats Stats) error {
+	byKind := [][]string{{bunt.Sprint("*change kind*"), bunt.Sprint("*count*")}}
+	for _, kind := range changeKinds {
+		if count, ok := stats.ByKind[kind]; ok {
+			byKind = append(byKind, []string{yellow("%s %s", indicator(kind), KindName(kind)), strconv.Itoa(count)})
+		}
+	}
+
+	tables := [][][]string{
+		byKind,
+		countTable("top-level key", stats.ByTopLevelKey),
+	}
+
+	if len(stats.ByResource) > 0 {
+		tables = append(tables, countTable("resource", stats.ByResource))
+	}
+
+	out.WriteString("\n")
+	out.WriteString(bunt.Sprint("*statistics*") + fmt.Sprintf(" of %s\n", text.Plural(stats.Differences, "difference")))
+	for _, table := range tables {
+		if len(table) == 1 {
+			continue
+		}
+
+		output, err := neat.Table(table, neat.CustomSeparator("   "), neat.AlignRight(1))
+		if err != nil {
+			return err
+		}
+
+		out.WriteString("\n")
+		out.WriteString(createStringWithPrefix("  ", strings.TrimRight(output, "\n")))
+	}
+
+	return nil
+}
+
+// countTable creates a table of the given counts with the highest count first
+func countTable(title string, counts map[string]int) [][]string {
+	keys := make([]string, 0, len(counts))
+	for key := range counts {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool {
+		if counts[keys[i]] != counts[keys[j]] {
+			return counts[keys[i]] > counts[keys[j]]
+		}
+
+		return keys[i] < keys[j]
+	})
+
+	table := [][]string{{bunt.Sprintf("*%s*", title), bunt.Sprint("*count*")}}
+	for _, key := range keys {
+		table = append(table, []string{key, strconv.Itoa(counts[key])})
+	}
+
+	return table
+}
@@ -0,0 +1,406 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gonvenience/bunt"
+	"github.com/gonvenience/neat"
+	"github.com/lucasb-eyer/go-colorful"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Theme defines the colors used to render reports and documents
+type Theme struct {
+	Name string
+
+	// Colors used for the change indicators and text values
+	Addition     colorful.Color
+	Modification colorful.Color
+	Removal      colorful.Color
+
+	// Colors used for the three parts of the banner in the report header
+	BannerAddition     colorful.Color
+	BannerModification colorful.Color
+	BannerRemoval      colorful.Color
+
+	// Colors used for the unchanged parts of a highlighted string change
+	AdditionLight colorful.Color
+	RemovalLight  colorful.Color
+
+	// Color schemas for the neat output of added, removed, and plain documents
+	AdditionSchema map[string]colorful.Color
+	RemovalSchema  map[string]colorful.Color
+	DocumentSchema map[string]colorful.Color
+}
+
+// DarkTheme is the default theme and works best on dark terminal backgrounds
+var DarkTheme = Theme{
+	Name:          "dark",
+	Addition:      color("#58BF38"),
+	Modification:  color("#C7C43F"),
+	Removal:       color("#B9311B"),
+	AdditionLight: bunt.LightGreen,
+	RemovalLight:  bunt.LightSalmon,
+
+	// The banner keeps the colors it had before themes were introduced
+	BannerAddition:     colorful.Color{R: .45, G: .71, B: .30},
+	BannerModification: colorful.Color{R: .79, G: .76, B: .38},
+	BannerRemoval:      colorful.Color{R: .65, G: .17, B: .17},
+	AdditionSchema: map[string]colorful.Color{
+		"keyColor":           bunt.Green,
+		"indentLineColor":    {R: 0, G: 0.2, B: 0},
+		"scalarDefaultColor": bunt.LimeGreen,
+		"boolColor":          bunt.LimeGreen,
+		"floatColor":         bunt.LimeGreen,
+		"intColor":           bunt.LimeGreen,
+		"multiLineTextColor": bunt.OliveDrab,
+		"nullColor":          bunt.Olive,
+		"emptyStructures":    bunt.DarkOliveGreen,
+		"dashColor":          bunt.Green,
+	},
+	RemovalSchema: map[string]colorful.Color{
+		"keyColor":           bunt.FireBrick,
+		"indentLineColor":    {R: 0.2, G: 0, B: 0},
+		"scalarDefaultColor": bunt.LightCoral,
+		"boolColor":          bunt.LightCoral,
+		"floatColor":         bunt.LightCoral,
+		"intColor":           bunt.LightCoral,
+		"multiLineTextColor": bunt.DarkSalmon,
+		"nullColor":          bunt.Salmon,
+		"emptyStructures":    bunt.LightSalmon,
+		"dashColor":          bunt.FireBrick,
+	},
+	DocumentSchema: neat.DefaultColorSchema,
+}
+
+// LightTheme uses darker colors that remain readable on light backgrounds
+var LightTheme = Theme{
+	Name:          "light",
+	Addition:      color("#2E7D32"),
+	Modification:  color("#8D6E00"),
+	Removal:       color("#C62828"),
+	AdditionLight: color("#558B2F"),
+	RemovalLight:  color("#AD5A4E"),
+
+	BannerAddition:     color("#2E7D32"),
+	BannerModification: color("#8D6E00"),
+	BannerRemoval:      color("#C62828"),
+	AdditionSchema: map[string]colorful.Color{
+		"keyColor":           bunt.DarkGreen,
+		"indentLineColor":    {R: 0.8, G: 0.9, B: 0.8},
+		"scalarDefaultColor": bunt.ForestGreen,
+		"boolColor":          bunt.ForestGreen,
+		"floatColor":         bunt.ForestGreen,
+		"intColor":           bunt.ForestGreen,
+		"multiLineTextColor": bunt.DarkOliveGreen,
+		"nullColor":          bunt.Olive,
+		"emptyStructures":    bunt.DarkOliveGreen,
+		"dashColor":          bunt.DarkGreen,
+	},
+	RemovalSchema: map[string]colorful.Color{
+		"keyColor":           bunt.DarkRed,
+		"indentLineColor":    {R: 0.9, G: 0.8, B: 0.8},
+		"scalarDefaultColor": bunt.Brown,
+		"boolColor":          bunt.Brown,
+		"floatColor":         bunt.Brown,
+		"intColor":           bunt.Brown,
+		"multiLineTextColor": bunt.Sienna,
+		"nullColor":          bunt.Maroon,
+		"emptyStructures":    bunt.Sienna,
+		"dashColor":          bunt.DarkRed,
+	},
+	DocumentSchema: map[string]colorful.Color{
+		"documentStart":      bunt.SlateGray,
+		"keyColor":           bunt.Brown,
+		"indentLineColor":    {R: 0.85, G: 0.85, B: 0.85},
+		"scalarDefaultColor": bunt.DarkGreen,
+		"boolColor":          bunt.DarkGoldenrod,
+		"floatColor":         bunt.Chocolate,
+		"intColor":           bunt.Indigo,
+		"multiLineTextColor": bunt.Teal,
+		"nullColor":          bunt.OrangeRed,
+		"binaryColor":        bunt.DarkCyan,
+		"emptyStructures":    bunt.DarkKhaki,
+		"commentColor":       bunt.Gray,
+		"anchorColor":        bunt.RoyalBlue,
+	},
+}
+
+// HighContrastTheme uses saturated colors with maximum contrast
+var HighContrastTheme = Theme{
+	Name:          "high-contrast",
+	Addition:      bunt.Lime,
+	Modification:  bunt.Yellow,
+	Removal:       bunt.Red,
+	AdditionLight: bunt.White,
+	RemovalLight:  bunt.White,
+
+	BannerAddition:     bunt.Lime,
+	BannerModification: bunt.Yellow,
+	BannerRemoval:      bunt.Red,
+	AdditionSchema: map[string]colorful.Color{
+		"keyColor":           bunt.Lime,
+		"indentLineColor":    bunt.Gray,
+		"scalarDefaultColor": bunt.White,
+		"boolColor":          bunt.White,
+		"floatColor":         bunt.White,
+		"intColor":           bunt.White,
+		"multiLineTextColor": bunt.White,
+		"nullColor":          bunt.White,
+		"emptyStructures":    bunt.White,
+		"dashColor":          bunt.Lime,
+	},
+	RemovalSchema: map[string]colorful.Color{
+		"keyColor":           bunt.Red,
+		"indentLineColor":    bunt.Gray,
+		"scalarDefaultColor": bunt.White,
+		"boolColor":          bunt.White,
+		"floatColor":         bunt.White,
+		"intColor":           bunt.White,
+		"multiLineTextColor": bunt.White,
+		"nullColor":          bunt.White,
+		"emptyStructures":    bunt.White,
+		"dashColor":          bunt.Red,
+	},
+	DocumentSchema: map[string]colorful.Color{
+		"documentStart":      bunt.White,
+		"keyColor":           bunt.Aqua,
+		"indentLineColor":    bunt.Gray,
+		"scalarDefaultColor": bunt.White,
+		"boolColor":          bunt.Yellow,
+		"floatColor":         bunt.Fuchsia,
+		"intColor":           bunt.Fuchsia,
+		"multiLineTextColor": bunt.White,
+		"nullColor":          bunt.Yellow,
+		"binaryColor":        bunt.Aqua,
+		"emptyStructures":    bunt.White,
+		"commentColor":       bunt.Silver,
+		"anchorColor":        bunt.Aqua,
+	},
+}
+
+// ColorBlindTheme replaces the red/green pair with blue and orange, which
+// remain distinguishable for the common forms of color vision deficiency
+var ColorBlindTheme = Theme{
+	Name:          "color-blind",
+	Addition:      color("#0072B2"),
+	Modification:  color("#F0E442"),
+	Removal:       color("#E69F00"),
+	AdditionLight: color("#56B4E9"),
+	RemovalLight:  color("#F5C26B"),
+
+	BannerAddition:     color("#0072B2"),
+	BannerModification: color("#F0E442"),
+	BannerRemoval:      color("#E69F00"),
+	AdditionSchema: map[string]colorful.Color{
+		"keyColor":           color("#0072B2"),
+		"indentLineColor":    {R: 0, G: 0.1, B: 0.2},
+		"scalarDefaultColor": color("#56B4E9"),
+		"boolColor":          color("#56B4E9"),
+		"floatColor":         color("#56B4E9"),
+		"intColor":           color("#56B4E9"),
+		"multiLineTextColor": bunt.LightSteelBlue,
+		"nullColor":          bunt.SteelBlue,
+		"emptyStructures":    bunt.LightSteelBlue,
+		"dashColor":          color("#0072B2"),
+	},
+	RemovalSchema: map[string]colorful.Color{
+		"keyColor":           color("#E69F00"),
+		"indentLineColor":    {R: 0.2, G: 0.1, B: 0},
+		"scalarDefaultColor": color("#F5C26B"),
+		"boolColor":          color("#F5C26B"),
+		"floatColor":         color("#F5C26B"),
+		"intColor":           color("#F5C26B"),
+		"multiLineTextColor": bunt.NavajoWhite,
+		"nullColor":          bunt.DarkOrange,
+		"emptyStructures":    bunt.NavajoWhite,
+		"dashColor":          color("#E69F00"),
+	},
+	DocumentSchema: neat.DefaultColorSchema,
+}
+
+// Themes contains all built-in themes by name
+var Themes = map[string]Theme{
+	DarkTheme.Name:         DarkTheme,
+	LightTheme.Name:        LightTheme,
+	HighContrastTheme.Name: HighContrastTheme,
+	ColorBlindTheme.Name:   ColorBlindTheme,
+}
+
+var currentTheme = DarkTheme
+
+// SetTheme sets the theme used for all subsequent output
+func SetTheme(theme Theme) {
+	currentTheme = theme
+}
+
+// CurrentTheme returns the theme currently used for output
+func CurrentTheme() Theme {
+	return currentTheme
+}
+
+// ThemeNames returns the sorted list of names of the built-in themes
+func ThemeNames() []string {
+	names := make([]string, 0, len(Themes))
+	for name := range Themes {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// LoadTheme returns the built-in theme with the given name, or loads the
+// theme from the given file location if there is no theme with that name
+func LoadTheme(nameOrLocation string) (Theme, error) {
+	if theme, ok := Themes[strings.ToLower(nameOrLocation)]; ok {
+		return theme, nil
+	}
+
+	if _, err := os.Stat(nameOrLocation); err != nil {
+		return Theme{}, fmt.Errorf("there is no built-in theme or theme file named %s, built-in themes are: %s",
+			nameOrLocation,
+			strings.Join(ThemeNames(), ", "),
+		)
+	}
+
+	data, err := ioutil.ReadFile(nameOrLocation)
+	if err != nil {
+		return Theme{}, err
+	}
+
+	return ParseTheme(data)
+}
+
+// themeFile is the representation of a theme in a theme file, where all colors
+// are specified using hex strings (for example #58BF38)
+type themeFile struct {
+	Name           string            `yaml:"name"`
+	Base           string            `yaml:"base"`
+	Addition       string            `yaml:"addition"`
+	Modification   string            `yaml:"modification"`
+	Removal        string            `yaml:"removal"`
+	AdditionLight  string            `yaml:"additionLight"`
+	RemovalLight   string            `yaml:"removalLight"`
+	AdditionSchema map[string]string `yaml:"additionSchema"`
+	RemovalSchema  map[string]string `yaml:"removalSchema"`
+	DocumentSchema map[string]string `yaml:"documentSchema"`
+}
+
+// ParseTheme parses the provided theme file data. A theme file only needs to
+// list the colors that differ from its base theme, which can be selected by
+// name using the base key and defaults to the dark theme.
+func ParseTheme(data []byte) (Theme, error) {
+	var definition themeFile
+	if err := yamlv3.Unmarshal(data, &definition); err != nil {
+		return Theme{}, fmt.Errorf("failed to parse theme: %w", err)
+	}
+
+	baseName := definition.Base
+	if baseName == "" {
+		baseName = DarkTheme.Name
+	}
+
+	base, ok := Themes[strings.ToLower(baseName)]
+	if !ok {
+		return Theme{}, fmt.Errorf("unknown base theme %s, built-in themes are: %s",
+			baseName,
+			strings.Join(ThemeNames(), ", "),
+		)
+	}
+
+	theme := base
+	theme.Name = definition.Name
+	if theme.Name == "" {
+		theme.Name = "custom"
+	}
+
+	for _, entry := range []struct {
+		hex    string
+		target *colorful.Color
+	}{
+		{definition.Addition, &theme.Addition},
+		{definition.Addition, &theme.BannerAddition},
+		{definition.Modification, &theme.Modification},
+		{definition.Modification, &theme.BannerModification},
+		{definition.Removal, &theme.Removal},
+		{definition.Removal, &theme.BannerRemoval},
+		{definition.AdditionLight, &theme.AdditionLight},
+		{definition.RemovalLight, &theme.RemovalLight},
+	} {
+		if entry.hex == "" {
+			continue
+		}
+
+		value, err := colorful.Hex(entry.hex)
+		if err != nil {
+			return Theme{}, fmt.Errorf("failed to parse color %s: %w", entry.hex, err)
+		}
+
+		*entry.target = value
+	}
+
+	var err error
+	if theme.AdditionSchema, err = mergeColorSchema(base.AdditionSchema, definition.AdditionSchema); err != nil {
+		return Theme{}, err
+	}
+
+	if theme.RemovalSchema, err = mergeColorSchema(base.RemovalSchema, definition.RemovalSchema); err != nil {
+		return Theme{}, err
+	}
+
+	if theme.DocumentSchema, err = mergeColorSchema(base.DocumentSchema, definition.DocumentSchema); err != nil {
+		return Theme{}, err
+	}
+
+	return theme, nil
+}
+
+func mergeColorSchema(base map[string]colorful.Color, overrides map[string]string) (map[string]colorful.Color, error) {
+	result := make(map[string]colorful.Color, len(base)+len(overrides))
+	for key, value := range base {
+		result[key] = value
+	}
+
+	for key, hex := range overrides {
+		value, err := colorful.Hex(hex)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse color %s of %s: %w", hex, key, err)
+		}
+
+		result[key] = value
+	}
+
+	return result, nil
+}
+
+// DocumentColorSchema returns the color schema of the current theme that is
+// used to render plain documents
+func DocumentColorSchema() *map[string]colorful.Color {
+	schema := currentTheme.DocumentSchema
+	return &schema
+}
@@ -0,0 +1,112 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff_test
+
+import (
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/lucasb-eyer/go-colorful"
+
+	. "github.com/homeport/dyff/pkg/dyff"
+)
+
+var _ = Describe("themes", func() {
+	hex := func(value string) colorful.Color {
+		color, err := colorful.Hex(value)
+		Expect(err).ToNot(HaveOccurred())
+		return color
+	}
+
+	Context("built-in themes", func() {
+		It("should provide a theme for each supported name", func() {
+			Expect(ThemeNames()).To(Equal([]string{"color-blind", "dark", "high-contrast", "light"}))
+
+			for _, name := range ThemeNames() {
+				theme, err := LoadTheme(name)
+				Expect(err).ToNot(HaveOccurred())
+				Expect(theme.Name).To(Equal(name))
+				Expect(theme.AdditionSchema).ToNot(BeEmpty())
+				Expect(theme.RemovalSchema).ToNot(BeEmpty())
+				Expect(theme.DocumentSchema).ToNot(BeEmpty())
+			}
+		})
+
+		It("should keep the original banner colors in the default theme", func() {
+			Expect(DarkTheme.BannerAddition).To(Equal(colorful.Color{R: .45, G: .71, B: .30}))
+			Expect(DarkTheme.BannerModification).To(Equal(colorful.Color{R: .79, G: .76, B: .38}))
+			Expect(DarkTheme.BannerRemoval).To(Equal(colorful.Color{R: .65, G: .17, B: .17}))
+		})
+
+		It("should fail for unknown themes", func() {
+			_, err := LoadTheme("does-not-exist")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("built-in themes are: color-blind, dark, high-contrast, light"))
+		})
+	})
+
+	Context("theme files", func() {
+		It("should only override the colors that are defined in the file", func() {
+			theme, err := ParseTheme([]byte(`---
+name: mine
+base: light
+addition: "#0000FF"
+removalSchema:
+  keyColor: "#FF00FF"
+`))
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("mine"))
+			Expect(theme.Addition).To(Equal(hex("#0000FF")))
+			Expect(theme.BannerAddition).To(Equal(hex("#0000FF")))
+			Expect(theme.Removal).To(Equal(LightTheme.Removal))
+			Expect(theme.RemovalSchema["keyColor"]).To(Equal(hex("#FF00FF")))
+			Expect(theme.RemovalSchema["dashColor"]).To(Equal(LightTheme.RemovalSchema["dashColor"]))
+			Expect(LightTheme.RemovalSchema["keyColor"]).ToNot(Equal(hex("#FF00FF")))
+		})
+
+		It("should load a theme from a file location", func() {
+			file, err := ioutil.TempFile("", "theme")
+			Expect(err).ToNot(HaveOccurred())
+			defer os.Remove(file.Name())
+
+			_, err = file.WriteString(`removal: "#E69F00"`)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(file.Close()).To(Succeed())
+
+			theme, err := LoadTheme(file.Name())
+			Expect(err).ToNot(HaveOccurred())
+			Expect(theme.Name).To(Equal("custom"))
+			Expect(theme.Removal).To(Equal(hex("#E69F00")))
+			Expect(theme.Addition).To(Equal(DarkTheme.Addition))
+		})
+
+		It("should fail on invalid colors or base themes", func() {
+			_, err := ParseTheme([]byte(`addition: "green"`))
+			Expect(err).To(HaveOccurred())
+
+			_, err = ParseTheme([]byte(`base: sepia`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,115 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/gonvenience/ytbx"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// Synthetic locations of the input files that are created for in-memory data
+const (
+	fromLocation = "from"
+	toLocation   = "to"
+)
+
+// InputFileFromBytes creates an input file from the YAML, JSON, or TOML data,
+// which can contain multiple documents. The name is used as the location of the
+// input file, for example in the report header.
+func InputFileFromBytes(name string, data []byte) (ytbx.InputFile, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load %s, because it is empty", name)
+	}
+
+	documents, err := ytbx.LoadDocuments(data)
+	if err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to load %s: %w", name, err)
+	}
+
+	return ytbx.InputFile{
+		Location:  name,
+		Documents: documents,
+	}, nil
+}
+
+// InputFileFromNodes creates an input file with the given nodes as documents,
+// the name is used as the location of the input file
+func InputFileFromNodes(name string, nodes ...*yamlv3.Node) ytbx.InputFile {
+	return ytbx.InputFile{
+		Location:  name,
+		Documents: nodes,
+	}
+}
+
+// CompareNodes compares two YAML nodes, which can be document nodes or any
+// other node, for example a mapping. The input files of the report use the
+// synthetic locations "from" and "to".
+func CompareNodes(from *yamlv3.Node, to *yamlv3.Node, compareOptions ...CompareOption) (Report, error) {
+	return CompareInputFiles(
+		InputFileFromNodes(fromLocation, from),
+		InputFileFromNodes(toLocation, to),
+		compareOptions...,
+	)
+}
+
+// CompareValues compares two Go values, for example structs of rendered
+// configuration objects. The values are converted to YAML nodes using the
+// rules of yaml.v3, i.e. the yaml struct tags are used, and the type of the
+// value is noted in the respective input file of the report.
+func CompareValues(from interface{}, to interface{}, compareOptions ...CompareOption) (Report, error) {
+	fromFile, err := inputFileFromValue(fromLocation, from)
+	if err != nil {
+		return Report{}, err
+	}
+
+	toFile, err := inputFileFromValue(toLocation, to)
+	if err != nil {
+		return Report{}, err
+	}
+
+	return CompareInputFiles(fromFile, toFile, compareOptions...)
+}
+
+func inputFileFromValue(name string, value interface{}) (inputFile ytbx.InputFile, err error) {
+	if node, ok := value.(*yamlv3.Node); ok {
+		return InputFileFromNodes(name, node), nil
+	}
+
+	// The yaml.v3 encoder panics for values that it cannot marshal, for
+	// example functions or channels, which is turned into an error here
+	defer func() {
+		if r := recover(); r != nil {
+			inputFile, err = ytbx.InputFile{}, fmt.Errorf("failed to convert %s value of type %T to YAML: %v", name, value, r)
+		}
+	}()
+
+	var node yamlv3.Node
+	if err := node.Encode(value); err != nil {
+		return ytbx.InputFile{}, fmt.Errorf("failed to convert %s value of type %T to YAML: %w", name, value, err)
+	}
+
+	inputFile = InputFileFromNodes(name, &node)
+	inputFile.Note = fmt.Sprintf("Go value of type %T", value)
+	return inputFile, nil
+}
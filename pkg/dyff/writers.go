@@ -0,0 +1,250 @@
+// Copyright © 2021 The Homeport Team
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package dyff
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// ReportWriterOptions are the output settings that are passed to the factory
+// of a report style. The common settings are used by the styles they apply to,
+// the style specific settings contain the values of all options the style
+// declared by option name, where options that are not set have their default.
+type ReportWriterOptions struct {
+	NoTableStyle      bool
+	DoNotInspectCerts bool
+	OmitHeader        bool
+	UseGoPatchPaths   bool
+	MaxValueLines     int
+	MaxValueBytes     int
+	ContextLines      int
+	ShowStats         bool
+
+	Settings map[string]string
+}
+
+// ReportStyleOption describes an option that is specific to one report style
+type ReportStyleOption struct {
+	Name        string
+	Description string
+	Default     string
+}
+
+// ReportStyle is a named output style, that creates report writers
+type ReportStyle struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Options     []ReportStyleOption
+
+	New func(report Report, options ReportWriterOptions) (ReportWriter, error)
+}
+
+var reportStyles = struct {
+	sync.RWMutex
+	styles []ReportStyle
+}{}
+
+// RegisterReportStyle adds a report style to the registry, so that it can be
+// used by its name or one of its aliases, for example as an output style of
+// the command-line interface, that the cli package provides to other tools.
+// The name and aliases must not be in use yet.
+func RegisterReportStyle(style ReportStyle) error {
+	if style.Name == "" || style.New == nil {
+		return fmt.Errorf("failed to register report style, a name and a factory function are required")
+	}
+
+	reportStyles.Lock()
+	defer reportStyles.Unlock()
+
+	for _, name := range append([]string{style.Name}, style.Aliases...) {
+		if _, ok := lookupReportStyle(name); ok {
+			return fmt.Errorf("failed to register report style %s, because the name %s is already in use", style.Name, name)
+		}
+	}
+
+	reportStyles.styles = append(reportStyles.styles, style)
+	return nil
+}
+
+// unregisterReportStyle removes the report style with the given name from the
+// registry, which is only meant for tests
+func unregisterReportStyle(name string) {
+	reportStyles.Lock()
+	defer reportStyles.Unlock()
+
+	for idx, style := range reportStyles.styles {
+		if style.Name == name {
+			reportStyles.styles = append(reportStyles.styles[:idx], reportStyles.styles[idx+1:]...)
+			return
+		}
+	}
+}
+
+// ReportStyles returns all registered report styles in the order of their
+// registration, starting with the built-in styles
+func ReportStyles() []ReportStyle {
+	reportStyles.RLock()
+	defer reportStyles.RUnlock()
+
+	result := make([]ReportStyle, len(reportStyles.styles))
+	copy(result, reportStyles.styles)
+	return result
+}
+
+// LookupReportStyle returns the report style with the given name or alias,
+// which is not case-sensitive
+func LookupReportStyle(name string) (ReportStyle, bool) {
+	reportStyles.RLock()
+	defer reportStyles.RUnlock()
+
+	return lookupReportStyle(name)
+}
+
+func lookupReportStyle(name string) (ReportStyle, bool) {
+	for _, style := range reportStyles.styles {
+		for _, candidate := range append([]string{style.Name}, style.Aliases...) {
+			if strings.EqualFold(candidate, name) {
+				return style, true
+			}
+		}
+	}
+
+	return ReportStyle{}, false
+}
+
+// NewReportWriter creates a report writer for the report using the report
+// style with the given name, which fails in case there is no such style, or
+// in case a style specific option is set that the style does not support
+func NewReportWriter(name string, report Report, options ReportWriterOptions) (ReportWriter, error) {
+	style, ok := LookupReportStyle(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown output style %s, supported styles are: %s", name, strings.Join(ReportStyleNames(), ", "))
+	}
+
+	settings := map[string]string{}
+	for _, option := range style.Options {
+		settings[option.Name] = option.Default
+	}
+
+	for name, value := range options.Settings {
+		if !style.hasOption(name) {
+			return nil, fmt.Errorf("output style %s does not support option %s", style.Name, name)
+		}
+
+		settings[name] = value
+	}
+
+	options.Settings = settings
+	return style.New(report, options)
+}
+
+// ReportStyleNames returns the names of all registered report styles
+func ReportStyleNames() []string {
+	styles := ReportStyles()
+	names := make([]string, len(styles))
+	for i, style := range styles {
+		names[i] = style.Name
+	}
+
+	return names
+}
+
+func (style ReportStyle) hasOption(name string) bool {
+	for _, option := range style.Options {
+		if option.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// builtinReportStyles are the report styles that come with dyff
+var builtinReportStyles = []ReportStyle{
+	{
+		Name:        "human",
+		Aliases:     []string{"bosh"},
+		Description: "human readable report with the changed values side by side",
+		Options: []ReportStyleOption{
+			{
+				Name:        "minor-change-threshold",
+				Description: "ratio of changed characters up to which a string change is highlighted within the value",
+				Default:     "0.1",
+			},
+		},
+		New: newHumanReport,
+	},
+	{
+		Name:        "brief",
+		Aliases:     []string{"short", "summary"},
+		Description: "one line summary of the differences",
+		New: func(report Report, _ ReportWriterOptions) (ReportWriter, error) {
+			return &BriefReport{Report: report}, nil
+		},
+	},
+	{
+		Name:        "stats",
+		Aliases:     []string{"statistics"},
+		Description: "statistics of the differences by change kind, top-level key, and Kubernetes resource",
+		New: func(report Report, _ ReportWriterOptions) (ReportWriter, error) {
+			return &StatsReport{Report: report}, nil
+		},
+	},
+	{
+		Name:        "json",
+		Description: "report as JSON, which can be compared with other reports",
+		New: func(report Report, options ReportWriterOptions) (ReportWriter, error) {
+			return &JSONReport{Report: report, ShowStats: options.ShowStats}, nil
+		},
+	},
+}
+
+func newHumanReport(report Report, options ReportWriterOptions) (ReportWriter, error) {
+	threshold, err := strconv.ParseFloat(options.Settings["minor-change-threshold"], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid minor-change-threshold option: %w", err)
+	}
+
+	return &HumanReport{
+		Report:               report,
+		DoNotInspectCerts:    options.DoNotInspectCerts,
+		NoTableStyle:         options.NoTableStyle,
+		OmitHeader:           options.OmitHeader,
+		UseGoPatchPaths:      options.UseGoPatchPaths,
+		MinorChangeThreshold: threshold,
+		MaxValueLines:        options.MaxValueLines,
+		MaxValueBytes:        options.MaxValueBytes,
+		ContextLines:         options.ContextLines,
+		ShowStats:            options.ShowStats,
+	}, nil
+}
+
+func init() {
+	for _, style := range builtinReportStyles {
+		if err := RegisterReportStyle(style); err != nil {
+			panic(err)
+		}
+	}
+}